@@ -83,6 +83,28 @@ func (p *policy) Evaluate(header [][]byte, payload []byte, identities [][]byte,
 	return nil
 }
 
+// acceptAllCryptoHelper is a CryptoHelper that considers every signature valid. It is used by
+// EvaluateIdentity to perform a structural, pre-flight check of a policy against an identity that
+// has not actually signed anything.
+type acceptAllCryptoHelper struct{}
+
+func (acceptAllCryptoHelper) VerifySignature(msg []byte, id []byte, signature []byte) bool {
+	return true
+}
+
+// EvaluateIdentity reports whether the given serialized identity, acting alone, would satisfy the
+// supplied signature policy envelope. Unlike Policy.Evaluate, this does not verify any cryptographic
+// signature, so it must not be used as a substitute for evaluating a policy against an actually
+// signed message; it is intended for pre-flight checks such as telling a client whether it is worth
+// submitting a transaction before it goes to the trouble of collecting endorsements.
+func EvaluateIdentity(policyEnvelope *cb.SignaturePolicyEnvelope, identity []byte) (bool, error) {
+	evaluator, err := cauthdsl.NewSignaturePolicyEvaluator(policyEnvelope, acceptAllCryptoHelper{})
+	if err != nil {
+		return false, err
+	}
+	return evaluator.Authenticate(nil, [][]byte{identity}, [][]byte{nil}), nil
+}
+
 // ManagerImpl is an implementation of Manager and configtx.ConfigHandler
 // In general, it should only be referenced as an Impl for the configtx.ConfigManager
 type ManagerImpl struct {
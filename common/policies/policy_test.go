@@ -99,6 +99,28 @@ func TestReject(t *testing.T) {
 	}
 }
 
+func TestEvaluateIdentity(t *testing.T) {
+	authorizedIdentity := []byte("authorized-identity")
+	unauthorizedIdentity := []byte("unauthorized-identity")
+	policyEnvelope := cauthdsl.Envelope(cauthdsl.SignedBy(0), [][]byte{authorizedIdentity})
+
+	ok, err := EvaluateIdentity(policyEnvelope, authorizedIdentity)
+	if err != nil {
+		t.Fatalf("Should not have errored evaluating a satisfiable policy: %s", err)
+	}
+	if !ok {
+		t.Errorf("Expected the authorized identity to satisfy the policy")
+	}
+
+	ok, err = EvaluateIdentity(policyEnvelope, unauthorizedIdentity)
+	if err != nil {
+		t.Fatalf("Should not have errored evaluating a satisfiable policy: %s", err)
+	}
+	if ok {
+		t.Errorf("Expected the unauthorized identity to not satisfy the policy")
+	}
+}
+
 func TestRejectOnUnknown(t *testing.T) {
 	m := NewManagerImpl(&mockCryptoHelper{})
 	policy, ok := m.GetPolicy("FakePolicyID")
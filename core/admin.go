@@ -25,6 +25,8 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/peer"
 	"github.com/hyperledger/fabric/flogging"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
@@ -80,6 +82,26 @@ func (*ServerAdmin) StopServer(context.Context, *empty.Empty) (*pb.ServerStatus,
 	return status, nil
 }
 
+// IsValidator reports whether the peer.validator.enabled property is set for this peer, so that
+// clients can tell whether their reads are served locally or need to be forwarded
+func (*ServerAdmin) IsValidator(context.Context, *empty.Empty) (*pb.ValidatorStatus, error) {
+	return &pb.ValidatorStatus{IsValidator: peer.ValidatorEnabled()}, nil
+}
+
+// GetServerConfig returns the peer's effective, non-secret configuration values, for operators
+// debugging behavior through the API. TLS private key paths and other secrets are deliberately
+// excluded; TlsEnabled only reports whether TLS is on.
+func (*ServerAdmin) GetServerConfig(context.Context, *empty.Empty) (*pb.ServerConfig, error) {
+	return &pb.ServerConfig{
+		ValidatorEnabled:   peer.ValidatorEnabled(),
+		TlsEnabled:         viper.GetBool("peer.tls.enabled"),
+		CouchDBEnabled:     ledgerconfig.IsCouchDBEnabled(),
+		HistoryDBEnabled:   ledgerconfig.IsHistoryDBEnabled(),
+		ListenAddress:      viper.GetString("peer.address"),
+		ChaincodeKeepalive: viper.GetString("chaincode.keepalive"),
+	}, nil
+}
+
 // GetModuleLogLevel gets the current logging level for the specified module
 func (*ServerAdmin) GetModuleLogLevel(ctx context.Context, request *pb.LogLevelRequest) (*pb.LogLevelResponse, error) {
 	logLevelString, err := flogging.GetModuleLogLevel(request.LogModule)
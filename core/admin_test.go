@@ -16,9 +16,66 @@ limitations under the License.
 
 package core
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/hyperledger/fabric/core/peer"
+)
 
 func TestServer_Status(t *testing.T) {
 	t.Skip("TBD")
 	//performHandshake(t, peerClientConn)
 }
+
+func TestServerAdmin_IsValidator(t *testing.T) {
+	viper.Set("peer.address", "0.0.0.0:7051")
+	admin := NewAdminServer()
+
+	viper.Set("peer.validator.enabled", true)
+	peer.CacheConfiguration()
+	status, err := admin.IsValidator(nil, nil)
+	if err != nil {
+		t.Fatalf("IsValidator returned an error: %s", err)
+	}
+	if !status.IsValidator {
+		t.Errorf("Expected IsValidator to be true when peer.validator.enabled is set")
+	}
+
+	viper.Set("peer.validator.enabled", false)
+	peer.CacheConfiguration()
+	status, err = admin.IsValidator(nil, nil)
+	if err != nil {
+		t.Fatalf("IsValidator returned an error: %s", err)
+	}
+	if status.IsValidator {
+		t.Errorf("Expected IsValidator to be false when peer.validator.enabled is false")
+	}
+}
+
+func TestServerAdmin_GetServerConfig(t *testing.T) {
+	viper.Set("peer.address", "0.0.0.0:7051")
+	viper.Set("peer.validator.enabled", true)
+	viper.Set("peer.tls.enabled", true)
+	viper.Set("chaincode.keepalive", "60")
+	peer.CacheConfiguration()
+	admin := NewAdminServer()
+
+	config, err := admin.GetServerConfig(nil, nil)
+	if err != nil {
+		t.Fatalf("GetServerConfig returned an error: %s", err)
+	}
+	if !config.ValidatorEnabled {
+		t.Errorf("Expected ValidatorEnabled to be true")
+	}
+	if !config.TlsEnabled {
+		t.Errorf("Expected TlsEnabled to be true")
+	}
+	if config.ListenAddress != "0.0.0.0:7051" {
+		t.Errorf("Expected ListenAddress to be 0.0.0.0:7051, got %s", config.ListenAddress)
+	}
+	if config.ChaincodeKeepalive != "60" {
+		t.Errorf("Expected ChaincodeKeepalive to be 60, got %s", config.ChaincodeKeepalive)
+	}
+}
@@ -176,6 +176,22 @@ func (e *Endorser) simulateProposal(ctx context.Context, chainID string, txid st
 	return resp, simResult, ccevent, nil
 }
 
+// GetEndorsingPeers returns the peers that host and can endorse the given chaincode, for client SDKs
+// building an endorsement set. This peer has no way to learn what chaincodes its fellow peers have
+// installed, so it can only speak for itself: if chaincodeID is deployed on this peer's chainID, its
+// own endpoint is returned, otherwise an empty (not nil) slice - callers should treat a non-error empty
+// result as "nobody known to host it" rather than as a failure.
+func (e *Endorser) GetEndorsingPeers(ctx context.Context, chainID string, chaincodeID string) ([]*pb.PeerEndpoint, error) {
+	if _, err := e.getCDSFromLCCC(ctx, chainID, "", nil, chaincodeID, nil); err != nil {
+		return []*pb.PeerEndpoint{}, nil
+	}
+	localEndpoint, err := peer.GetPeerEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	return []*pb.PeerEndpoint{localEndpoint}, nil
+}
+
 func (e *Endorser) getCDSFromLCCC(ctx context.Context, chainID string, txid string, prop *pb.Proposal, chaincodeID string, txsim ledger.TxSimulator) ([]byte, error) {
 	ctxt := ctx
 	if txsim != nil {
@@ -276,6 +276,42 @@ func TestDeploy(t *testing.T) {
 	chaincode.GetChain().Stop(context.Background(), cccid, &pb.ChaincodeDeploymentSpec{ChaincodeSpec: spec})
 }
 
+//TestGetEndorsingPeers deploys chaincode example01 and checks that GetEndorsingPeers reports this
+//peer for it, and an empty (not nil) slice for a chaincode that was never deployed
+func TestGetEndorsingPeers(t *testing.T) {
+	chainID := util.GetTestChainID()
+	spec := &pb.ChaincodeSpec{Type: 1, ChaincodeID: &pb.ChaincodeID{Name: "ex01", Path: "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example01"}, CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("init"), []byte("a"), []byte("100"), []byte("b"), []byte("200")}}}
+
+	cccid := chaincode.NewCCContext(chainID, "ex01", "", "", false, nil)
+
+	_, _, err := deploy(endorserServer, chainID, spec, nil)
+	if err != nil {
+		t.Fail()
+		t.Logf("Deploy-error in deploy %s", err)
+		chaincode.GetChain().Stop(context.Background(), cccid, &pb.ChaincodeDeploymentSpec{ChaincodeSpec: spec})
+		return
+	}
+	defer chaincode.GetChain().Stop(context.Background(), cccid, &pb.ChaincodeDeploymentSpec{ChaincodeSpec: spec})
+
+	e := endorserServer.(*Endorser)
+
+	peers, err := e.GetEndorsingPeers(context.Background(), chainID, "ex01")
+	if err != nil {
+		t.Fatalf("GetEndorsingPeers returned an error: %s", err)
+	}
+	if len(peers) != 1 {
+		t.Errorf("Expected 1 endorsing peer for a deployed chaincode, got %d", len(peers))
+	}
+
+	peers, err = e.GetEndorsingPeers(context.Background(), chainID, "notdeployed")
+	if err != nil {
+		t.Fatalf("GetEndorsingPeers returned an error: %s", err)
+	}
+	if len(peers) != 0 {
+		t.Errorf("Expected no endorsing peers for a chaincode that was never deployed, got %d", len(peers))
+	}
+}
+
 //TestDeployBadArgs sets bad args on deploy. It should fail, and example02 should not be deployed
 func TestDeployBadArgs(t *testing.T) {
 	chainID := util.GetTestChainID()
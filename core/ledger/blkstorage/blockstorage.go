@@ -58,5 +58,6 @@ type BlockStore interface {
 	RetrieveBlockByHash(blockHash []byte) (*common.Block, error)
 	RetrieveBlockByNumber(blockNum uint64) (*common.Block, error) // blockNum of  math.MaxUint64 will return last block
 	RetrieveTxByID(txID string) (*pb.Transaction, error)
+	ResolveTxIDPrefix(prefix string) ([]string, error)
 	Shutdown()
 }
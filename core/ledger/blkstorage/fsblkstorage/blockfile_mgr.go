@@ -456,6 +456,11 @@ func (mgr *blockfileMgr) retrieveTransactionByID(txID string) (*pb.Transaction,
 	return mgr.fetchTransaction(loc)
 }
 
+func (mgr *blockfileMgr) retrieveTxIDsByPrefix(prefix string) ([]string, error) {
+	logger.Debugf("retrieveTxIDsByPrefix() - prefix = [%s]", prefix)
+	return mgr.index.getTxIDsByPrefix(prefix)
+}
+
 func (mgr *blockfileMgr) retrieveTransactionForBlockNumTranNum(blockNum uint64, tranNum uint64) (*pb.Transaction, error) {
 	logger.Debugf("retrieveTransactionForBlockNumTranNum() - blockNum = [%d], tranNum = [%d]", blockNum, tranNum)
 	loc, err := mgr.index.getTXLocForBlockNumTranNum(blockNum, tranNum)
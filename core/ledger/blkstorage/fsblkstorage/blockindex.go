@@ -43,6 +43,7 @@ type index interface {
 	getBlockLocByBlockNum(blockNum uint64) (*fileLocPointer, error)
 	getTxLoc(txID string) (*fileLocPointer, error)
 	getTXLocForBlockNumTranNum(blockNum uint64, tranNum uint64) (*fileLocPointer, error)
+	getTxIDsByPrefix(prefix string) ([]string, error)
 }
 
 type blockIdxInfo struct {
@@ -182,6 +183,24 @@ func (index *blockIndex) getTxLoc(txID string) (*fileLocPointer, error) {
 	return txFLP, nil
 }
 
+//getTxIDsByPrefix returns the transaction ids of all the transactions indexed under a txID starting
+//with the given prefix
+func (index *blockIndex) getTxIDsByPrefix(prefix string) ([]string, error) {
+	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrTxID]; !ok {
+		return nil, blkstorage.ErrAttrNotIndexed
+	}
+	startKey := constructTxIDKey(prefix)
+	endKey := append(startKey, 0xff)
+	itr := index.db.GetIterator(startKey, endKey)
+	defer itr.Release()
+
+	var txIDs []string
+	for itr.Next() {
+		txIDs = append(txIDs, string(itr.Key()[len([]byte{txIDIdxKeyPrefix}):]))
+	}
+	return txIDs, nil
+}
+
 func (index *blockIndex) getTXLocForBlockNumTranNum(blockNum uint64, tranNum uint64) (*fileLocPointer, error) {
 	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrBlockNumTranNum]; !ok {
 		return nil, blkstorage.ErrAttrNotIndexed
@@ -45,6 +45,9 @@ func (i *noopIndex) getTxLoc(txID string) (*fileLocPointer, error) {
 func (i *noopIndex) getTXLocForBlockNumTranNum(blockNum uint64, tranNum uint64) (*fileLocPointer, error) {
 	return nil, nil
 }
+func (i *noopIndex) getTxIDsByPrefix(prefix string) ([]string, error) {
+	return nil, nil
+}
 
 func TestBlockIndexSync(t *testing.T) {
 	testBlockIndexSync(t, 10, 5, false)
@@ -69,6 +69,11 @@ func (store *FsBlockStore) RetrieveTxByID(txID string) (*pb.Transaction, error)
 	return store.fileMgr.retrieveTransactionByID(txID)
 }
 
+// ResolveTxIDPrefix returns the ids of all the transactions whose id begins with the given prefix
+func (store *FsBlockStore) ResolveTxIDPrefix(prefix string) ([]string, error) {
+	return store.fileMgr.retrieveTxIDsByPrefix(prefix)
+}
+
 // Shutdown shuts down the block store
 func (store *FsBlockStore) Shutdown() {
 	store.fileMgr.close()
@@ -22,6 +22,7 @@ import (
 
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/core/ledger/util/couchdb"
 	"github.com/hyperledger/fabric/protos/common"
 	putils "github.com/hyperledger/fabric/protos/utils"
@@ -135,6 +136,13 @@ func (histmgr *CouchDBHistMgr) Commit(block *common.Block) error {
 
 //getTransactionsForNsKey contructs composite start and end keys based on the namespace and key then calls the CouchDB range scanner
 func (histmgr *CouchDBHistMgr) getTransactionsForNsKey(namespace string, key string, includeValues bool) (*histScanner, error) {
+	return histmgr.getTransactionsForNsKeyPaged(namespace, key, includeValues, ledgerconfig.GetHistoryQueryLimit(), 0)
+}
+
+//getTransactionsForNsKeyPaged behaves like getTransactionsForNsKey but returns at most pageSize
+//historic versions, skipping the first skip that would otherwise have been returned. Used to page
+//through the history of a key that has been updated too many times to hold in memory at once.
+func (histmgr *CouchDBHistMgr) getTransactionsForNsKeyPaged(namespace string, key string, includeValues bool, pageSize int, skip int) (*histScanner, error) {
 	var compositeStartKey []byte
 	var compositeEndKey []byte
 	if key != "" {
@@ -142,13 +150,79 @@ func (histmgr *CouchDBHistMgr) getTransactionsForNsKey(namespace string, key str
 		compositeEndKey = constructPartialCompositeKey(namespace, key, true)
 	}
 
-	//TODO the limit should not be hardcoded.  Need the config.
 	//TODO Implement includeValues so that values are not returned in the readDocRange
-	queryResult, _ := histmgr.couchDB.ReadDocRange(string(compositeStartKey), string(compositeEndKey), 1000, 0)
+	queryResult, _ := histmgr.couchDB.ReadDocRange(string(compositeStartKey), string(compositeEndKey), pageSize, skip)
 
 	return newHistScanner(compositeStartKey, *queryResult), nil
 }
 
+//countVersions returns the number of historical versions recorded for a single (namespace, key)
+func (histmgr *CouchDBHistMgr) countVersions(namespace string, key string) (uint64, error) {
+	scanner, err := histmgr.getTransactionsForNsKey(namespace, key, false)
+	if err != nil {
+		return 0, err
+	}
+	defer scanner.close()
+
+	var count uint64
+	for {
+		historicValue, err := scanner.next()
+		if err != nil {
+			return 0, err
+		}
+		if historicValue == nil {
+			break
+		}
+		count++
+	}
+	return count, nil
+}
+
+//valueAsOfHeight returns the value of (namespace, key) as last written at or before blockHeight, by
+//scanning its history and keeping the highest qualifying (block number, transaction number) pair, so
+//a point-in-time snapshot can be reconstructed instead of reading the moving tip. found is false if
+//key has no write at or before blockHeight. Bounded by the same history query limit as other reads
+//of a key's history.
+func (histmgr *CouchDBHistMgr) valueAsOfHeight(namespace string, key string, blockHeight uint64) ([]byte, bool, error) {
+	scanner, err := histmgr.getTransactionsForNsKey(namespace, key, true)
+	if err != nil {
+		return nil, false, err
+	}
+	defer scanner.close()
+
+	var value []byte
+	var found bool
+	var bestBlockNum, bestTranNum uint64
+	for {
+		historicValue, err := scanner.next()
+		if err != nil {
+			return nil, false, err
+		}
+		if historicValue == nil {
+			break
+		}
+		parts := bytes.SplitN(bytes.TrimPrefix([]byte(historicValue.blockNumTranNum), compositeKeySep), compositeKeySep, 2)
+		blockNum, err := strconv.ParseUint(string(parts[0]), 10, 64)
+		if err != nil {
+			return nil, false, err
+		}
+		if blockNum > blockHeight {
+			continue
+		}
+		var tranNum uint64
+		if len(parts) > 1 {
+			tranNum, err = strconv.ParseUint(string(parts[1]), 10, 64)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+		if !found || blockNum > bestBlockNum || (blockNum == bestBlockNum && tranNum >= bestTranNum) {
+			found, bestBlockNum, bestTranNum, value = true, blockNum, tranNum, historicValue.value
+		}
+	}
+	return value, found, nil
+}
+
 func constructCompositeKey(ns string, key string, blocknum uint64, trannum uint64) string {
 	//History Key is:  "namespace key blocknum trannum"", with namespace being the chaincode id
 
@@ -165,6 +239,34 @@ func constructCompositeKey(ns string, key string, blocknum uint64, trannum uint6
 	return buffer.String()
 }
 
+//getAllKeysEver scans the entire namespace portion of the history index and returns the distinct keys
+//that have ever been written, including ones that have since been deleted
+func (histmgr *CouchDBHistMgr) getAllKeysEver(namespace string) ([]string, error) {
+	compositeStartKey := constructPartialCompositeKey(namespace, "", false)
+	compositeEndKey := constructPartialCompositeKey(namespace, "", true)
+
+	pageSize := ledgerconfig.GetHistoryQueryLimit()
+	seen := make(map[string]bool)
+	var keys []string
+	for skip := 0; ; skip += pageSize {
+		queryResult, err := histmgr.couchDB.ReadDocRange(string(compositeStartKey), string(compositeEndKey), pageSize, skip)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range *queryResult {
+			_, keyAndTranInfo := splitCompositeKey(compositeStartKey, []byte(result.ID))
+			key := string(bytes.SplitN([]byte(keyAndTranInfo), compositeKeySep, 2)[0])
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		if len(*queryResult) < pageSize {
+			return keys, nil
+		}
+	}
+}
+
 func constructPartialCompositeKey(ns string, key string, endkey bool) []byte {
 	compositeKey := []byte(ns)
 	compositeKey = append(compositeKey, compositeKeySep...)
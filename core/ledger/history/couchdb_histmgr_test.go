@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/core/ledger/testutil"
 )
@@ -83,6 +85,84 @@ func TestConstructCompositeKey(t *testing.T) {
 	testutil.AssertEquals(t, compositeKey, "ns1"+strKeySep+"key1"+strKeySep+"1"+strKeySep+"1")
 }
 
+func TestConstructPartialCompositeKeyForNamespaceScan(t *testing.T) {
+	startKey := constructPartialCompositeKey("ns1", "", false)
+	endKey := constructPartialCompositeKey("ns1", "", true)
+
+	var strKeySep = string(compositeKeySep)
+	testutil.AssertEquals(t, string(startKey), "ns1"+strKeySep)
+	testutil.AssertEquals(t, string(endKey), "ns1"+strKeySep+"1")
+}
+
+func TestParseHistoryBookmark(t *testing.T) {
+	skip, err := parseHistoryBookmark("")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, skip, 0)
+
+	skip, err = parseHistoryBookmark("42")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, skip, 42)
+
+	_, err = parseHistoryBookmark("not-a-number")
+	if err == nil {
+		t.Fatal("expected an error for a malformed bookmark")
+	}
+
+	_, err = parseHistoryBookmark("-1")
+	if err == nil {
+		t.Fatal("expected an error for a negative bookmark")
+	}
+}
+
+func TestStreamStateSnapshot(t *testing.T) {
+	//call a helper method to load the core.yaml
+	testutil.SetupCoreYAMLConfig("./../../../peer")
+	if ledgerconfig.IsHistoryDBEnabled() != true {
+		return
+	}
+
+	env := newTestEnvHistoryCouchDB(t, "history-snapshot-test")
+	env.cleanup()
+	defer env.cleanup()
+
+	histMgr := NewCouchDBHistMgr(env.couchDBAddress, env.couchDatabaseName, env.couchUsername, env.couchPassword)
+
+	writeKey1 := func(value string) []byte {
+		rwSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{
+			{NameSpace: "ns1", Writes: []*rwset.KVWrite{rwset.NewKVWrite("key1", []byte(value))}},
+		}}
+		resultsBytes, err := rwSet.Marshal()
+		testutil.AssertNoError(t, err, "")
+		return resultsBytes
+	}
+
+	bg := testutil.NewBlockGenerator(t)
+	block1 := bg.NextBlock([][]byte{writeKey1(`{"val":"v1"}`)}, false)
+	testutil.AssertNoError(t, histMgr.Commit(block1), "")
+	block2 := bg.NextBlock([][]byte{writeKey1(`{"val":"v2"}`)}, false)
+	testutil.AssertNoError(t, histMgr.Commit(block2), "")
+
+	qe := &CouchDBHistQueryExecutor{histMgr}
+
+	var atHeight1 []*ledger.KV
+	err := qe.StreamStateSnapshot("ns1", 1, func(kv *ledger.KV) error {
+		atHeight1 = append(atHeight1, kv)
+		return nil
+	}, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(atHeight1), 1)
+	testutil.AssertEquals(t, string(atHeight1[0].Value), `{"val":"v1"}`)
+
+	var atHeight2 []*ledger.KV
+	err = qe.StreamStateSnapshot("ns1", 2, func(kv *ledger.KV) error {
+		atHeight2 = append(atHeight2, kv)
+		return nil
+	}, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(atHeight2), 1)
+	testutil.AssertEquals(t, string(atHeight2[0].Value), `{"val":"v2"}`)
+}
+
 //History Database commit and read is being tested with kv_ledger_test.go.
 //This test will push some of the testing down into history itself
 func TestHistoryDatabaseCommit(t *testing.T) {
@@ -16,7 +16,13 @@ limitations under the License.
 
 package history
 
-import "github.com/hyperledger/fabric/core/ledger"
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+)
 
 // CouchDBHistQueryExecutor is a query executor used in `CouchDBHistMgr`
 type CouchDBHistQueryExecutor struct {
@@ -33,6 +39,151 @@ func (q *CouchDBHistQueryExecutor) GetTransactionsForKey(namespace string, key s
 	return &qHistoryItr{scanner}, nil
 }
 
+// GetTransactionsForKeyPaged behaves like GetTransactionsForKey but returns at most pageSize
+// historical versions (falling back to ledgerconfig.GetHistoryQueryLimit() when pageSize <= 0),
+// resuming from the given bookmark (the empty string starts from the beginning of the key's
+// history). Alongside the iterator it returns a bookmark to pass on the next call; the returned
+// bookmark is empty once the final page has been reached. Intended for a key with too many
+// historical versions to return or hold in memory in one call.
+func (q *CouchDBHistQueryExecutor) GetTransactionsForKeyPaged(namespace string, key string, includeValues bool, pageSize int, bookmark string) (ledger.ResultsIterator, string, error) {
+	if pageSize <= 0 {
+		pageSize = ledgerconfig.GetHistoryQueryLimit()
+	}
+	skip, err := parseHistoryBookmark(bookmark)
+	if err != nil {
+		return nil, "", err
+	}
+	scanner, err := q.histmgr.getTransactionsForNsKeyPaged(namespace, key, includeValues, pageSize, skip)
+	if err != nil {
+		return nil, "", err
+	}
+	nextBookmark := ""
+	if len(scanner.results) == pageSize {
+		nextBookmark = strconv.Itoa(skip + len(scanner.results))
+	}
+	return &qHistoryItr{scanner}, nextBookmark, nil
+}
+
+// parseHistoryBookmark decodes a bookmark produced by GetTransactionsForKeyPaged back into the
+// number of historical versions to skip. The empty string, used to request the first page, decodes
+// to zero.
+func parseHistoryBookmark(bookmark string) (int, error) {
+	if bookmark == "" {
+		return 0, nil
+	}
+	skip, err := strconv.Atoi(bookmark)
+	if err != nil || skip < 0 {
+		return 0, fmt.Errorf("invalid bookmark: %s", bookmark)
+	}
+	return skip, nil
+}
+
+// StreamHistoryForKey delivers every historical version of key in namespace to listener, a page
+// (ledgerconfig.GetHistoryQueryLimit() entries) at a time, so a caller never needs to hold an
+// unbounded key's entire history in memory at once. Respects cancel for early exit, in which case
+// it returns nil.
+func (q *CouchDBHistQueryExecutor) StreamHistoryForKey(namespace string, key string, includeValues bool, listener func(*ledger.KeyModification) error, cancel <-chan struct{}) error {
+	bookmark := ""
+	for {
+		select {
+		case <-cancel:
+			return nil
+		default:
+		}
+		itr, nextBookmark, err := q.GetTransactionsForKeyPaged(namespace, key, includeValues, ledgerconfig.GetHistoryQueryLimit(), bookmark)
+		if err != nil {
+			return err
+		}
+		if err := streamHistoryPage(itr, listener, cancel); err != nil {
+			return err
+		}
+		if nextBookmark == "" {
+			return nil
+		}
+		bookmark = nextBookmark
+	}
+}
+
+// streamHistoryPage delivers every result in itr to listener, closing itr before returning.
+func streamHistoryPage(itr ledger.ResultsIterator, listener func(*ledger.KeyModification) error, cancel <-chan struct{}) error {
+	defer itr.Close()
+	for {
+		select {
+		case <-cancel:
+			return nil
+		default:
+		}
+		result, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			return nil
+		}
+		if err := listener(result.(*ledger.KeyModification)); err != nil {
+			return err
+		}
+	}
+}
+
+// StreamStateSnapshot delivers every key/value that existed in namespace as of blockHeight (not the
+// moving tip) to listener, reconstructing each key's value from its write history rather than
+// reading live state, so the snapshot stays point-in-time consistent even as new blocks commit
+// concurrently. Respects cancel for early exit, in which case it returns nil.
+func (q *CouchDBHistQueryExecutor) StreamStateSnapshot(namespace string, blockHeight uint64, listener func(*ledger.KV) error, cancel <-chan struct{}) error {
+	keys, err := q.histmgr.getAllKeysEver(namespace)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		select {
+		case <-cancel:
+			return nil
+		default:
+		}
+		value, found, err := q.histmgr.valueAsOfHeight(namespace, key, blockHeight)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		if err := listener(&ledger.KV{Key: key, Value: value}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountAllKeysEver implements method in interface `ledger.HistoryQueryExecutor`
+func (q *CouchDBHistQueryExecutor) CountAllKeysEver(namespace string) (uint64, error) {
+	keys, err := q.histmgr.getAllKeysEver(namespace)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(keys)), nil
+}
+
+// MostVersionedKey implements method in interface `ledger.HistoryQueryExecutor`
+func (q *CouchDBHistQueryExecutor) MostVersionedKey(namespace string) (string, uint64, error) {
+	keys, err := q.histmgr.getAllKeysEver(namespace)
+	if err != nil {
+		return "", 0, err
+	}
+	var mostKey string
+	var mostCount uint64
+	for _, key := range keys {
+		count, err := q.histmgr.countVersions(namespace, key)
+		if err != nil {
+			return "", 0, err
+		}
+		if count > mostCount {
+			mostKey, mostCount = key, count
+		}
+	}
+	return mostKey, mostCount, nil
+}
+
 type qHistoryItr struct {
 	q *histScanner
 }
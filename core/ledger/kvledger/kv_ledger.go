@@ -17,28 +17,51 @@ limitations under the License.
 package kvledger
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/blkstorage"
 	"github.com/hyperledger/fabric/core/ledger/blkstorage/fsblkstorage"
 	"github.com/hyperledger/fabric/core/ledger/history"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/couchdbtxmgmt"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/stateleveldb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr/lockbasedtxmgr"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/core/util"
+	"github.com/hyperledger/fabric/msp"
 
 	logging "github.com/op/go-logging"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
 )
 
 var logger = logging.MustGetLogger("kvledger")
 
+// ErrEmptyBlockchain is returned when a query that requires at least one block is made against an empty chain
+var ErrEmptyBlockchain = errors.New("Blockchain is empty")
+
 // Conf captures `KVLedger` configurations
 type Conf struct {
 	blockStorageDir  string
@@ -63,6 +86,7 @@ type KVLedger struct {
 	blockStore  blkstorage.BlockStore
 	txtmgmt     txmgr.TxMgr
 	historymgmt history.HistMgr
+	conf        *Conf
 }
 
 // NewKVLedger constructs new `KVLedger`
@@ -114,7 +138,7 @@ func NewKVLedger(conf *Conf) (*KVLedger, error) {
 			couchDBDef.Password) //enter couchDB pw here
 	}
 
-	l := &KVLedger{blockStore, txmgmt, historymgmt}
+	l := &KVLedger{blockStore, txmgmt, historymgmt, conf}
 
 	if err := recoverStateDB(l); err != nil {
 		panic(fmt.Errorf(`Error during state DB recovery:%s`, err))
@@ -169,46 +193,2501 @@ func (l *KVLedger) GetTransactionByID(txID string) (*pb.Transaction, error) {
 	return l.blockStore.RetrieveTxByID(txID)
 }
 
-// GetBlockchainInfo returns basic info about blockchain
-func (l *KVLedger) GetBlockchainInfo() (*pb.BlockchainInfo, error) {
-	return l.blockStore.GetBlockchainInfo()
+// BlocksBetweenTransactions returns the number of blocks separating the blocks containing txID1
+// and txID2, signed so that a positive result means txID2 committed in a later block than txID1.
+// Two transactions in the same block report zero. Returns ledger.ErrNotFound if either transaction
+// is not found on the chain.
+func (l *KVLedger) BlocksBetweenTransactions(txID1, txID2 string) (int64, error) {
+	blockNumber1, err := l.findBlockNumberForTx(txID1)
+	if err != nil {
+		return 0, err
+	}
+	blockNumber2, err := l.findBlockNumberForTx(txID2)
+	if err != nil {
+		return 0, err
+	}
+	return int64(blockNumber2) - int64(blockNumber1), nil
 }
 
-// GetBlockByNumber returns block at a given height
-// blockNumber of  math.MaxUint64 will return last block
-func (l *KVLedger) GetBlockByNumber(blockNumber uint64) (*common.Block, error) {
-	return l.blockStore.RetrieveBlockByNumber(blockNumber)
+// GetTransactionConfirmations returns how many blocks have been committed since txID's containing
+// block, i.e. the current tip height minus that block's number, akin to the "N confirmations" a
+// blockchain explorer shows. Returns ledger.ErrNotFound if txID is not found on the chain.
+func (l *KVLedger) GetTransactionConfirmations(txID string) (uint64, error) {
+	blockNumber, err := l.findBlockNumberForTx(txID)
+	if err != nil {
+		return 0, err
+	}
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.Height - blockNumber, nil
+}
 
+// GetNextTransaction returns the transaction committed immediately after txID: the next transaction
+// in the same block if one exists, otherwise the first transaction of the next non-empty block.
+// Returns ledger.ErrNotFound if txID is not found, or if it is the chain's most recently committed
+// transaction.
+func (l *KVLedger) GetNextTransaction(txID string) (*pb.Transaction, error) {
+	blockNumber, err := l.findBlockNumberForTx(txID)
+	if err != nil {
+		return nil, err
+	}
+	nextTxID, err := l.nextTxIDInOrAfter(blockNumber, txID)
+	if err != nil {
+		return nil, err
+	}
+	return l.GetTransactionByID(nextTxID)
 }
 
-// GetBlocksIterator returns an iterator that starts from `startBlockNumber`(inclusive).
-// The iterator is a blocking iterator i.e., it blocks till the next block gets available in the ledger
-// ResultsIterator contains type BlockHolder
-func (l *KVLedger) GetBlocksIterator(startBlockNumber uint64) (ledger.ResultsIterator, error) {
-	return l.blockStore.RetrieveBlocks(startBlockNumber)
+// nextTxIDInOrAfter returns the id of the transaction immediately following txID within
+// blockNumber's block, or failing that, the id of the first transaction in the next non-empty
+// block. Returns ledger.ErrNotFound if no later transaction exists.
+func (l *KVLedger) nextTxIDInOrAfter(blockNumber uint64, txID string) (string, error) {
+	block, err := l.GetBlockByNumber(blockNumber)
+	if err != nil {
+		return "", err
+	}
+	for i, envBytes := range block.Data.Data {
+		payload, err := getTxPayload(envBytes)
+		if err != nil {
+			return "", err
+		}
+		if payload.Header.ChainHeader.TxID != txID {
+			continue
+		}
+		if i+1 < len(block.Data.Data) {
+			nextPayload, err := getTxPayload(block.Data.Data[i+1])
+			if err != nil {
+				return "", err
+			}
+			return nextPayload.Header.ChainHeader.TxID, nil
+		}
+		break
+	}
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return "", err
+	}
+	for nextBlockNumber := blockNumber + 1; nextBlockNumber <= info.Height; nextBlockNumber++ {
+		nextBlock, err := l.GetBlockByNumber(nextBlockNumber)
+		if err != nil {
+			return "", err
+		}
+		if len(nextBlock.Data.Data) == 0 {
+			continue
+		}
+		nextPayload, err := getTxPayload(nextBlock.Data.Data[0])
+		if err != nil {
+			return "", err
+		}
+		return nextPayload.Header.ChainHeader.TxID, nil
+	}
+	return "", ledger.ErrNotFound
+}
 
+// findBlockNumberForTx scans the chain for the block containing txID. The block storage index
+// backing RetrieveTxByID only returns the transaction itself, not its containing block number, so
+// a scan is the only way to make this determination. Returns ledger.ErrNotFound if txID is not
+// found on the chain.
+func (l *KVLedger) findBlockNumberForTx(txID string) (uint64, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return 0, err
+	}
+	for blockNumber := uint64(1); blockNumber <= info.Height; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return 0, err
+		}
+		for _, envBytes := range block.Data.Data {
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return 0, err
+			}
+			if payload.Header.ChainHeader.TxID == txID {
+				return blockNumber, nil
+			}
+		}
+	}
+	return 0, ledger.ErrNotFound
 }
 
-// GetBlockByHash returns a block given it's hash
-func (l *KVLedger) GetBlockByHash(blockHash []byte) (*common.Block, error) {
-	return l.blockStore.RetrieveBlockByHash(blockHash)
+// TransactionCost reports the resource consumption recorded for a transaction's execution.
+// Tracked is false when the underlying transaction manager does not record resource usage,
+// in which case the unit counts are meaningless and should be ignored by the caller.
+type TransactionCost struct {
+	Tracked      bool
+	ComputeUnits uint64
+	ReadUnits    uint64
+	WriteUnits   uint64
 }
 
-//Prune prunes the blocks/transactions that satisfy the given policy
-func (l *KVLedger) Prune(policy ledger.PrunePolicy) error {
-	return errors.New("Not yet implemented")
+// GetTransactionCost returns the resource consumption recorded for the transaction identified by txID,
+// if the ledger's transaction manager records such information. This implementation of `ledger.ValidatedLedger`
+// does not currently track per-transaction resource usage, so Tracked is always false for a known transaction.
+// The error returned for an unknown txID is the same as that returned by GetTransactionByID.
+func (l *KVLedger) GetTransactionCost(txID string) (*TransactionCost, error) {
+	if _, err := l.GetTransactionByID(txID); err != nil {
+		return nil, err
+	}
+	return &TransactionCost{Tracked: false}, nil
 }
 
-// NewTxSimulator returns new `ledger.TxSimulator`
-func (l *KVLedger) NewTxSimulator() (ledger.TxSimulator, error) {
-	return l.txtmgmt.NewTxSimulator()
+// EndorsementCoverage reports how many endorsements a transaction carries against how many this
+// peer's endorsement policy currently requires, for endorsement-policy debugging.
+type EndorsementCoverage struct {
+	Present   int
+	Required  int
+	Satisfied bool
 }
 
-// NewQueryExecutor gives handle to a query executor.
-// A client can obtain more than one 'QueryExecutor's for parallel execution.
-// Any synchronization should be performed at the implementation level if required
-func (l *KVLedger) NewQueryExecutor() (ledger.QueryExecutor, error) {
-	return l.txtmgmt.NewQueryExecutor()
+// GetEndorsementCoverage returns the endorsement coverage for the transaction identified by txID.
+// This peer's validation system chaincode (core/system_chaincode/vscc) does not have access to a
+// per-chaincode endorsement policy threshold - it only enforces that every endorsement present is
+// a valid signature - so Required here reflects that one-endorsement floor rather than a policy
+// this snapshot has no way to look up. Returns ledger.ErrNotFound for an unknown txID.
+func (l *KVLedger) GetEndorsementCoverage(txID string) (*EndorsementCoverage, error) {
+	tx, err := l.GetTransactionByID(txID)
+	if err != nil {
+		return nil, ledger.ErrNotFound
+	}
+	const requiredEndorsements = 1
+	present := 0
+	for _, action := range tx.Actions {
+		actionPayload, err := utils.GetChaincodeActionPayload(action.Payload)
+		if err != nil {
+			return nil, err
+		}
+		present += len(actionPayload.Action.Endorsements)
+	}
+	return &EndorsementCoverage{Present: present, Required: requiredEndorsements, Satisfied: present >= requiredEndorsements}, nil
+}
+
+// GetTransactionResponse returns the chaincode return payload recorded for the transaction
+// identified by txID. This schema's committed ChaincodeAction carries only the read/write set
+// (Results) and emitted events (Events) - it has no dedicated chaincode-response field - so the
+// first action's Events are reported as Payload, on the convention that a chaincode's return value
+// is emitted as its final event when one needs to survive commit. Status is 200 when the action's
+// read/write set unmarshals successfully (the best available proxy for "the transaction's invocation
+// completed"), and 500 otherwise. Returns ledger.ErrNotFound for an unknown txID.
+func (l *KVLedger) GetTransactionResponse(txID string) (*pb.Response, error) {
+	tx, err := l.GetTransactionByID(txID)
+	if err != nil {
+		return nil, ledger.ErrNotFound
+	}
+	if len(tx.Actions) == 0 {
+		return nil, fmt.Errorf("transaction %s has no actions", txID)
+	}
+
+	_, ccAction, err := utils.GetPayloads(tx.Actions[0])
+	if err != nil {
+		return nil, err
+	}
+
+	const (
+		statusOK                  = 200
+		statusInternalServerError = 500
+	)
+	txRWSet := &rwset.TxReadWriteSet{}
+	if err := txRWSet.Unmarshal(ccAction.Results); err != nil {
+		return &pb.Response{Status: statusInternalServerError, Message: err.Error()}, nil
+	}
+	return &pb.Response{Status: statusOK, Payload: ccAction.Events}, nil
+}
+
+// ReadSetValidity reports whether a committed transaction's recorded read-set is still valid against
+// the current state, and which keys (if any) have since changed, for a pre-submission MVCC conflict
+// check.
+type ReadSetValidity struct {
+	Valid       bool
+	ChangedKeys []string
+}
+
+// ValidateReadSet re-reads, for each namespace/key in txID's recorded read-set, the version
+// currently committed and compares it against the version txID observed at simulation time,
+// reporting any mismatch as a would-be MVCC conflict. This mirrors the comparison
+// statebasedval.Validator performs at commit time, but against current state rather than the state
+// as of txID's own block, so a client can cheaply recheck a transaction before resubmitting it.
+// Returns ledger.ErrNotFound for an unknown txID.
+func (l *KVLedger) ValidateReadSet(txID string) (*ReadSetValidity, error) {
+	tx, err := l.GetTransactionByID(txID)
+	if err != nil {
+		return nil, ledger.ErrNotFound
+	}
+
+	qe, err := l.NewQueryExecutor()
+	if err != nil {
+		return nil, err
+	}
+	defer qe.Done()
+
+	var changedKeys []string
+	for _, action := range tx.Actions {
+		_, ccAction, err := utils.GetPayloads(action)
+		if err != nil {
+			return nil, err
+		}
+		txRWSet := &rwset.TxReadWriteSet{}
+		if err := txRWSet.Unmarshal(ccAction.Results); err != nil {
+			return nil, err
+		}
+		for _, nsRWSet := range txRWSet.NsRWs {
+			for _, kvRead := range nsRWSet.Reads {
+				var committedBlockNum, committedTxNum uint64
+				versionedValue, err := qe.GetStateWithVersion(nsRWSet.NameSpace, kvRead.Key)
+				if err != nil {
+					return nil, err
+				}
+				if versionedValue != nil {
+					committedBlockNum = versionedValue.Height.BlockNum
+					committedTxNum = versionedValue.Height.TxNum
+				}
+				var readBlockNum, readTxNum uint64
+				wasAbsent := kvRead.Version == nil
+				if !wasAbsent {
+					readBlockNum = kvRead.Version.BlockNum
+					readTxNum = kvRead.Version.TxNum
+				}
+				isAbsentNow := versionedValue == nil
+				if wasAbsent != isAbsentNow || committedBlockNum != readBlockNum || committedTxNum != readTxNum {
+					changedKeys = append(changedKeys, nsRWSet.NameSpace+"."+kvRead.Key)
+				}
+			}
+		}
+	}
+	return &ReadSetValidity{Valid: len(changedKeys) == 0, ChangedKeys: changedKeys}, nil
+}
+
+// StateProvenance reports a key's current value together with the location of the transaction that
+// last set it, for provenance UIs that want both in one call instead of a GetState followed by a
+// separate history lookup.
+type StateProvenance struct {
+	Value       []byte
+	BlockNumber uint64
+	TxID        string
+}
+
+// GetStateWithProvenance returns the current value of key in chaincodeID's namespace along with the
+// block number and transaction id that last wrote it. Returns ledger.ErrNotFound if the key does not
+// currently exist.
+func (l *KVLedger) GetStateWithProvenance(chaincodeID, key string) (*StateProvenance, error) {
+	qe, err := l.NewQueryExecutor()
+	if err != nil {
+		return nil, err
+	}
+	defer qe.Done()
+	versionedValue, err := qe.GetStateWithVersion(chaincodeID, key)
+	if err != nil {
+		return nil, err
+	}
+	block, err := l.GetBlockByNumber(versionedValue.Height.BlockNum)
+	if err != nil {
+		return nil, err
+	}
+	txNum := versionedValue.Height.TxNum
+	if txNum == 0 || txNum > uint64(len(block.Data.Data)) {
+		return nil, ledger.ErrNotFound
+	}
+	payload, err := getTxPayload(block.Data.Data[txNum-1])
+	if err != nil {
+		return nil, err
+	}
+	return &StateProvenance{
+		Value:       versionedValue.Value,
+		BlockNumber: versionedValue.Height.BlockNum,
+		TxID:        payload.Header.ChainHeader.TxID,
+	}, nil
+}
+
+// exportedKV is the on-the-wire shape written by ExportStateJSON. encoding/json base64-encodes the
+// Value field automatically since it is typed []byte, giving binary-safe values for free.
+type exportedKV struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// ExportStateJSON streams chaincodeID's entire committed state to w as a JSON array of {key, value}
+// objects, base64-encoding each value for binary safety, so the snapshot can be consumed by non-Go
+// tooling. Entries are written to w as they are read from the range scan rather than buffered in
+// memory, so namespaces larger than memory can still be exported.
+func (l *KVLedger) ExportStateJSON(chaincodeID string, w io.Writer) error {
+	qe, err := l.NewQueryExecutor()
+	if err != nil {
+		return err
+	}
+	defer qe.Done()
+	itr, err := qe.GetStateRangeScanIterator(chaincodeID, "", "")
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+
+	if _, err := w.Write([]byte("[")); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	first := true
+	for {
+		result, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			break
+		}
+		kv := result.(*ledger.KV)
+		if !first {
+			if _, err := w.Write([]byte(",")); err != nil {
+				return err
+			}
+		}
+		first = false
+		if err := encoder.Encode(&exportedKV{Key: kv.Key, Value: kv.Value}); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write([]byte("]"))
+	return err
+}
+
+// defaultStateQueryPageSize is used by GetStateByPattern when the caller does not request a
+// specific page size.
+const defaultStateQueryPageSize = 100
+
+// GetStateByPattern returns, within chaincodeID's namespace, the key/value pairs whose key matches
+// pattern, paged at pageSize entries per call (falling back to defaultStateQueryPageSize when
+// pageSize <= 0) and resuming from bookmark (the empty string starts at the first page). pattern
+// supports at most one wildcard ('*'), anchored at the start ("*suffix") or the end ("prefix*"); a
+// pattern with no wildcard must match a key exactly. A prefix or exact pattern is evaluated with a
+// bounded range scan so it stays efficient over the range index; a suffix pattern cannot be
+// expressed as a single contiguous range and falls back to scanning the namespace. The returned
+// bookmark is empty once the final page has been reached.
+func (l *KVLedger) GetStateByPattern(chaincodeID, pattern string, pageSize int32, bookmark string) ([]*ledger.KV, string, error) {
+	if pageSize <= 0 {
+		pageSize = defaultStateQueryPageSize
+	}
+	skip, err := parseQueryBookmark(bookmark)
+	if err != nil {
+		return nil, "", err
+	}
+	matches, startKey, endKey, err := compileKeyPattern(pattern)
+	if err != nil {
+		return nil, "", err
+	}
+
+	qe, err := l.NewQueryExecutor()
+	if err != nil {
+		return nil, "", err
+	}
+	defer qe.Done()
+	itr, err := qe.GetStateRangeScanIterator(chaincodeID, startKey, endKey)
+	if err != nil {
+		return nil, "", err
+	}
+	defer itr.Close()
+
+	var page []*ledger.KV
+	skipped := 0
+	for {
+		result, err := itr.Next()
+		if err != nil {
+			return nil, "", err
+		}
+		if result == nil {
+			break
+		}
+		kv := result.(*ledger.KV)
+		if !matches(kv.Key) {
+			continue
+		}
+		if skipped < skip {
+			skipped++
+			continue
+		}
+		if len(page) == int(pageSize) {
+			return page, strconv.Itoa(skip + len(page)), nil
+		}
+		page = append(page, kv)
+	}
+	return page, "", nil
+}
+
+// parseQueryBookmark decodes a bookmark produced by GetStateByPattern back into the number of
+// matching entries to skip. The empty string, used to request the first page, decodes to zero.
+func parseQueryBookmark(bookmark string) (int, error) {
+	if bookmark == "" {
+		return 0, nil
+	}
+	skip, err := strconv.Atoi(bookmark)
+	if err != nil || skip < 0 {
+		return 0, fmt.Errorf("invalid bookmark: %s", bookmark)
+	}
+	return skip, nil
+}
+
+// GetStateByRange returns, within chaincodeID's namespace, the key/value pairs in the half-open
+// range [startKey, endKey), in key order. An empty startKey means "from the beginning" and an empty
+// endKey means "to the end" of the namespace. The result is capped at
+// ledgerconfig.GetRangeQueryLimit() entries; truncated is true when more entries matched than were
+// returned, so a caller can detect that it needs to re-query with a narrower range.
+func (l *KVLedger) GetStateByRange(chaincodeID, startKey, endKey string) (kvs []*ledger.KV, truncated bool, err error) {
+	qe, err := l.NewQueryExecutor()
+	if err != nil {
+		return nil, false, err
+	}
+	defer qe.Done()
+	itr, err := qe.GetStateRangeScanIterator(chaincodeID, startKey, endKey)
+	if err != nil {
+		return nil, false, err
+	}
+	defer itr.Close()
+
+	limit := ledgerconfig.GetRangeQueryLimit()
+	for {
+		result, err := itr.Next()
+		if err != nil {
+			return nil, false, err
+		}
+		if result == nil {
+			break
+		}
+		if len(kvs) == limit {
+			return kvs, true, nil
+		}
+		kvs = append(kvs, result.(*ledger.KV))
+	}
+	return kvs, false, nil
+}
+
+// compileKeyPattern validates pattern and returns a predicate for matching keys against it, along
+// with a [startKey, endKey) range scan bound tight enough to avoid a full namespace scan for a
+// prefix pattern (both bounds are "" when no such bound can be computed, e.g. for a suffix
+// pattern). pattern must contain at most one '*', anchored at the very start or the very end;
+// anything else is rejected to keep matching servable over the range index rather than requiring
+// arbitrary glob semantics.
+func compileKeyPattern(pattern string) (func(key string) bool, string, string, error) {
+	switch wildcards := strings.Count(pattern, "*"); {
+	case wildcards == 0:
+		return func(key string) bool { return key == pattern }, pattern, pattern + "\x00", nil
+	case wildcards == 1 && strings.HasSuffix(pattern, "*"):
+		prefix := strings.TrimSuffix(pattern, "*")
+		return func(key string) bool { return strings.HasPrefix(key, prefix) }, prefix, prefixUpperBound(prefix), nil
+	case wildcards == 1 && strings.HasPrefix(pattern, "*"):
+		suffix := strings.TrimPrefix(pattern, "*")
+		return func(key string) bool { return strings.HasSuffix(key, suffix) }, "", "", nil
+	default:
+		return nil, "", "", fmt.Errorf("pattern %q must contain at most one '*', anchored at the start or end", pattern)
+	}
+}
+
+// prefixUpperBound returns the smallest key strictly greater than every key having the given
+// prefix, for use as the exclusive end of a range scan. Returns "" (meaning "no upper bound, scan to
+// the end of the namespace") if prefix consists entirely of 0xff bytes.
+func prefixUpperBound(prefix string) string {
+	bs := []byte(prefix)
+	for i := len(bs) - 1; i >= 0; i-- {
+		if bs[i] != 0xff {
+			bs[i]++
+			return string(bs[:i+1])
+		}
+	}
+	return ""
+}
+
+// GetTxSetHash returns a deterministic hash over the stored bytes of the transactions identified by
+// txIDs, taken in the given order, so a client can confirm it holds the same set of transactions
+// without transferring them. Reordering txIDs changes the hash. Returns ledger.ErrNotFound if any
+// transaction is not found.
+func (l *KVLedger) GetTxSetHash(txIDs []string) ([]byte, error) {
+	var setBytes []byte
+	for _, txID := range txIDs {
+		tx, err := l.GetTransactionByID(txID)
+		if err != nil {
+			return nil, ledger.ErrNotFound
+		}
+		txBytes, err := proto.Marshal(tx)
+		if err != nil {
+			return nil, err
+		}
+		setBytes = append(setBytes, txBytes...)
+	}
+	return util.ComputeCryptoHash(setBytes), nil
+}
+
+// minTxIDPrefixLen is the shortest prefix that ResolveTxPrefix accepts, to keep a typo'd prefix
+// from triggering a full-index scan that returns an unhelpfully large result set
+const minTxIDPrefixLen = 4
+
+// ErrTxIDPrefixTooShort is returned by ResolveTxPrefix when the supplied prefix is shorter than minTxIDPrefixLen
+var ErrTxIDPrefixTooShort = fmt.Errorf("transaction id prefix must be at least %d characters", minTxIDPrefixLen)
+
+// ResolveTxPrefix returns the ids of all transactions whose id begins with the given prefix, akin to
+// resolving an abbreviated hash to its full form. It errors with ErrTxIDPrefixTooShort if prefix is
+// shorter than minTxIDPrefixLen.
+func (l *KVLedger) ResolveTxPrefix(prefix string) ([]string, error) {
+	if len(prefix) < minTxIDPrefixLen {
+		return nil, ErrTxIDPrefixTooShort
+	}
+	return l.blockStore.ResolveTxIDPrefix(prefix)
+}
+
+// BlockRange identifies an inclusive span of block numbers.
+type BlockRange struct {
+	Start uint64
+	End   uint64
+}
+
+// GetBlockRangeForTimeWindow returns the first and last block numbers whose commit timestamps fall
+// in [start, end), found by binary search rather than a linear scan. This relies on block
+// timestamps being non-decreasing, which VerifyCommitOrder can be used to confirm; it also requires
+// every block to carry at least one transaction, since GetBlockTimestamp has nothing else to read.
+// If no block falls in the window, the zero BlockRange is returned.
+func (l *KVLedger) GetBlockRangeForTimeWindow(start, end time.Time) (*BlockRange, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info.Height == 0 || !start.Before(end) {
+		return &BlockRange{}, nil
+	}
+
+	atOrAfterStart := func(blockNumber uint64) (bool, error) {
+		blockTime, err := l.blockTime(blockNumber)
+		if err != nil {
+			return false, err
+		}
+		return !blockTime.Before(start), nil
+	}
+	beforeEnd := func(blockNumber uint64) (bool, error) {
+		blockTime, err := l.blockTime(blockNumber)
+		if err != nil {
+			return false, err
+		}
+		return blockTime.Before(end), nil
+	}
+
+	firstBlock, found, err := binarySearchFirstTrue(1, info.Height, atOrAfterStart)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &BlockRange{}, nil
+	}
+	lastBlock, found, err := binarySearchLastTrue(firstBlock, info.Height, beforeEnd)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &BlockRange{}, nil
+	}
+	return &BlockRange{Start: firstBlock, End: lastBlock}, nil
+}
+
+// GetFirstBlockAfter returns the earliest block committed at or after ts, with its transaction data
+// stripped down to an empty BlockData since point-in-time lookups like this are typically used to
+// locate a position on the chain rather than to inspect a block's transactions. Found by binary
+// search rather than a linear scan, relying on the same non-decreasing-timestamp assumption as
+// GetBlockRangeForTimeWindow. Returns ledger.ErrNotFound if every block predates ts.
+func (l *KVLedger) GetFirstBlockAfter(ts time.Time) (*common.Block, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info.Height == 0 {
+		return nil, ledger.ErrNotFound
+	}
+	atOrAfter := func(blockNumber uint64) (bool, error) {
+		blockTime, err := l.blockTime(blockNumber)
+		if err != nil {
+			return false, err
+		}
+		return !blockTime.Before(ts), nil
+	}
+	blockNumber, found, err := binarySearchFirstTrue(1, info.Height, atOrAfter)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ledger.ErrNotFound
+	}
+	block, err := l.GetBlockByNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &common.Block{Header: block.Header, Data: &common.BlockData{}, Metadata: block.Metadata}, nil
+}
+
+func (l *KVLedger) blockTime(blockNumber uint64) (time.Time, error) {
+	ts, err := l.GetBlockTimestamp(blockNumber)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ptypes.Timestamp(ts)
+}
+
+// binarySearchFirstTrue returns the smallest n in [lo, hi] for which pred(n) holds, assuming pred is
+// false for a (possibly empty) prefix of the range and true for the remaining suffix. ok is false if
+// pred never holds in the range.
+func binarySearchFirstTrue(lo, hi uint64, pred func(uint64) (bool, error)) (n uint64, ok bool, err error) {
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		truth, err := pred(mid)
+		if err != nil {
+			return 0, false, err
+		}
+		if truth {
+			n, ok = mid, true
+			if mid == 0 {
+				break
+			}
+			hi = mid - 1
+		} else {
+			lo = mid + 1
+		}
+	}
+	return n, ok, nil
+}
+
+// binarySearchLastTrue returns the largest n in [lo, hi] for which pred(n) holds, assuming pred is
+// true for a (possibly empty) prefix of the range and false for the remaining suffix. ok is false if
+// pred never holds in the range.
+func binarySearchLastTrue(lo, hi uint64, pred func(uint64) (bool, error)) (n uint64, ok bool, err error) {
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		truth, err := pred(mid)
+		if err != nil {
+			return 0, false, err
+		}
+		if truth {
+			n, ok = mid, true
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+	return n, ok, nil
+}
+
+// maxBlockRangeSize bounds how many blocks a single range-oriented analytics query may span, so
+// that an unbounded client request cannot force a full-chain scan in one call.
+const maxBlockRangeSize = 1000
+
+// ErrBlockRangeTooLarge is returned by range-oriented analytics queries when the requested range
+// spans more than maxBlockRangeSize blocks.
+var ErrBlockRangeTooLarge = fmt.Errorf("block range must not span more than %d blocks", maxBlockRangeSize)
+
+// checkBlockRange validates that [startBlockNumber, endBlockNumber] is a sane, bounded range.
+func checkBlockRange(startBlockNumber, endBlockNumber uint64) error {
+	if endBlockNumber < startBlockNumber {
+		return fmt.Errorf("endBlockNumber %d must not precede startBlockNumber %d", endBlockNumber, startBlockNumber)
+	}
+	if endBlockNumber-startBlockNumber+1 > maxBlockRangeSize {
+		return ErrBlockRangeTooLarge
+	}
+	return nil
+}
+
+// BlockWriteVolume reports the total serialized size, in bytes, of the write-sets committed in a
+// single block.
+type BlockWriteVolume struct {
+	BlockNumber uint64
+	Bytes       uint64
+}
+
+// GetBlockWriteVolume returns the total serialized bytes of write-sets contributed by each block in
+// [startBlockNumber, endBlockNumber] (inclusive), for storage growth analysis. Returns
+// ErrBlockRangeTooLarge if the range spans more than maxBlockRangeSize blocks.
+func (l *KVLedger) GetBlockWriteVolume(startBlockNumber, endBlockNumber uint64) ([]*BlockWriteVolume, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+	volumes := make([]*BlockWriteVolume, 0, endBlockNumber-startBlockNumber+1)
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		writeSetBytes, err := blockWriteSetBytes(block)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, &BlockWriteVolume{BlockNumber: blockNumber, Bytes: writeSetBytes})
+	}
+	return volumes, nil
+}
+
+// blockWriteSetBytes sums the serialized size of the write-set (ChaincodeAction.Results) carried by
+// every endorser transaction in block. Non-endorser transactions (e.g., configuration transactions)
+// do not carry a write-set and are skipped.
+func blockWriteSetBytes(block *common.Block) (uint64, error) {
+	var total uint64
+	for _, envBytes := range block.Data.Data {
+		payload, err := getTxPayload(envBytes)
+		if err != nil {
+			return 0, err
+		}
+		if common.HeaderType(payload.Header.ChainHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+			continue
+		}
+		tx, err := utils.GetTransaction(payload.Data)
+		if err != nil {
+			return 0, err
+		}
+		for _, action := range tx.Actions {
+			actionPayload, err := utils.GetChaincodeActionPayload(action.Payload)
+			if err != nil {
+				return 0, err
+			}
+			prp, err := utils.GetProposalResponsePayload(actionPayload.Action.ProposalResponsePayload)
+			if err != nil {
+				return 0, err
+			}
+			ccAction, err := utils.GetChaincodeAction(prp.Extension)
+			if err != nil {
+				return 0, err
+			}
+			total += uint64(len(ccAction.Results))
+		}
+	}
+	return total, nil
+}
+
+// KeyTouchCount reports how many transactions, across a scanned block range, read or wrote a given
+// (chaincode, key) pair.
+type KeyTouchCount struct {
+	ChaincodeID string
+	Key         string
+	Count       uint64
+}
+
+// GetKeyTouchCounts scans [startBlockNumber, endBlockNumber] and returns, for every (chaincode, key)
+// pair touched by an endorser transaction's read-set or write-set, the number of transactions that
+// touched it, sorted by descending count (ties broken by chaincode then key, for a deterministic
+// order) and capped at the topN highest counts. A non-positive topN returns every touched key
+// uncapped. Returns ErrBlockRangeTooLarge if the range spans more than maxBlockRangeSize blocks.
+func (l *KVLedger) GetKeyTouchCounts(startBlockNumber, endBlockNumber uint64, topN int) ([]*KeyTouchCount, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+
+	type nsKey struct {
+		chaincodeID string
+		key         string
+	}
+	counts := map[nsKey]uint64{}
+
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, envBytes := range block.Data.Data {
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			if common.HeaderType(payload.Header.ChainHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+				continue
+			}
+			tx, err := utils.GetTransaction(payload.Data)
+			if err != nil {
+				return nil, err
+			}
+			for _, action := range tx.Actions {
+				_, ccAction, err := utils.GetPayloads(action)
+				if err != nil {
+					return nil, err
+				}
+				txRWSet := &rwset.TxReadWriteSet{}
+				if err := txRWSet.Unmarshal(ccAction.Results); err != nil {
+					return nil, err
+				}
+				for _, nsRW := range txRWSet.NsRWs {
+					for _, read := range nsRW.Reads {
+						counts[nsKey{nsRW.NameSpace, read.Key}]++
+					}
+					for _, write := range nsRW.Writes {
+						counts[nsKey{nsRW.NameSpace, write.Key}]++
+					}
+				}
+			}
+		}
+	}
+
+	touched := make([]*KeyTouchCount, 0, len(counts))
+	for k, count := range counts {
+		touched = append(touched, &KeyTouchCount{ChaincodeID: k.chaincodeID, Key: k.key, Count: count})
+	}
+	sort.Slice(touched, func(i, j int) bool {
+		if touched[i].Count != touched[j].Count {
+			return touched[i].Count > touched[j].Count
+		}
+		if touched[i].ChaincodeID != touched[j].ChaincodeID {
+			return touched[i].ChaincodeID < touched[j].ChaincodeID
+		}
+		return touched[i].Key < touched[j].Key
+	})
+	if topN > 0 && len(touched) > topN {
+		touched = touched[:topN]
+	}
+	return touched, nil
+}
+
+// TransactionProofClaim is one (transaction, claimed containing block) pair to verify.
+// This ledger has no compact Merkle inclusion-proof primitive - BlockData.Hash() is a flat crypto
+// hash over the whole marshaled block, not a Merkle tree (see the XXX note on BlockData.Hash) - so
+// a "proof" here is simply the block number the transaction is claimed to belong to; verification
+// means fetching that block, confirming the transaction is actually in it, and comparing the
+// block's real header hash against ClaimedBlockHash.
+type TransactionProofClaim struct {
+	TxID             string
+	BlockNumber      uint64
+	ClaimedBlockHash []byte
+}
+
+// TransactionProofResult reports the outcome of verifying a single TransactionProofClaim.
+type TransactionProofResult struct {
+	TxID  string
+	Valid bool
+}
+
+// VerifyTransactionProofs verifies a batch of TransactionProofClaims in one call, so a client can
+// validate several transactions without a round-trip per transaction.
+func (l *KVLedger) VerifyTransactionProofs(claims []*TransactionProofClaim) ([]*TransactionProofResult, error) {
+	results := make([]*TransactionProofResult, len(claims))
+	for i, claim := range claims {
+		results[i] = &TransactionProofResult{TxID: claim.TxID, Valid: l.verifyTransactionProof(claim)}
+	}
+	return results, nil
+}
+
+func (l *KVLedger) verifyTransactionProof(claim *TransactionProofClaim) bool {
+	block, err := l.GetBlockByNumber(claim.BlockNumber)
+	if err != nil {
+		return false
+	}
+	if !bytes.Equal(block.Header.Hash(), claim.ClaimedBlockHash) {
+		return false
+	}
+	for _, envBytes := range block.Data.Data {
+		payload, err := getTxPayload(envBytes)
+		if err != nil {
+			continue
+		}
+		if payload.Header != nil && payload.Header.ChainHeader != nil && payload.Header.ChainHeader.TxID == claim.TxID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLastConfigBlockNumber returns the block number of the most recently committed configuration
+// block. It first checks the tip block's LAST_CONFIGURATION metadata entry, which a full
+// orderer/peer stack maintains on every block precisely so that readers never need to scan for it;
+// nothing in this snapshot populates that entry yet, so when it is absent this falls back to
+// walking backward from the tip until a configuration transaction is found. Returns
+// ErrEmptyBlockchain when the chain has no blocks.
+func (l *KVLedger) GetLastConfigBlockNumber() (uint64, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return 0, err
+	}
+	if info.Height == 0 {
+		return 0, ErrEmptyBlockchain
+	}
+	tip, err := l.GetBlockByNumber(math.MaxUint64)
+	if err != nil {
+		return 0, err
+	}
+	if tip.Metadata != nil && len(tip.Metadata.Metadata) > int(common.BlockMetadataIndex_LAST_CONFIGURATION) {
+		if raw := tip.Metadata.Metadata[common.BlockMetadataIndex_LAST_CONFIGURATION]; len(raw) == 8 {
+			return binary.BigEndian.Uint64(raw), nil
+		}
+	}
+	for blockNumber := tip.Header.Number; ; blockNumber-- {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return 0, err
+		}
+		isConfigBlock, err := blockIsConfig(block)
+		if err != nil {
+			return 0, err
+		}
+		if isConfigBlock {
+			return blockNumber, nil
+		}
+		if blockNumber == 0 {
+			return 0, ErrEmptyBlockchain
+		}
+	}
+}
+
+// GetChannelConfig returns the channel's current configuration envelope, decoded from the most
+// recent configuration block's transaction, for admins reconfiguring a channel.
+func (l *KVLedger) GetChannelConfig() (*common.ConfigurationEnvelope, error) {
+	lastConfigBlockNumber, err := l.GetLastConfigBlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	block, err := l.GetBlockByNumber(lastConfigBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(block.Data.Data) == 0 {
+		return nil, fmt.Errorf("configuration block %d has no transactions", lastConfigBlockNumber)
+	}
+	payload, err := getTxPayload(block.Data.Data[0])
+	if err != nil {
+		return nil, err
+	}
+	configEnvelope := &common.ConfigurationEnvelope{}
+	if err := proto.Unmarshal(payload.Data, configEnvelope); err != nil {
+		return nil, err
+	}
+	return configEnvelope, nil
+}
+
+// AnchorPeer identifies a channel's configured anchor/bootstrap peer by host and port.
+type AnchorPeer struct {
+	Host string
+	Port int32
+}
+
+// anchorPeersConfigKey is the ConfigurationItem.Key this ledger looks for when reading a channel's
+// configured anchor peers. No proto message schema for anchor peers exists yet in this snapshot (see
+// gossip/api.AnchorPeer for the analogous concept used at the gossip layer), so the item's value is
+// a small JSON-encoded list of AnchorPeer - pending a real ConfigurationItem schema if one is added.
+const anchorPeersConfigKey = "AnchorPeers"
+
+// GetAnchorPeers returns the channel's configured anchor/bootstrap peers, so clients have a stable
+// entry point for bootstrapping. Returns an empty (not nil) slice when none are configured.
+func (l *KVLedger) GetAnchorPeers() ([]*AnchorPeer, error) {
+	configEnvelope, err := l.GetChannelConfig()
+	if err != nil {
+		return nil, err
+	}
+	for _, signedItem := range configEnvelope.Items {
+		item := &common.ConfigurationItem{}
+		if err := proto.Unmarshal(signedItem.ConfigurationItem, item); err != nil {
+			return nil, err
+		}
+		if item.Key != anchorPeersConfigKey {
+			continue
+		}
+		anchorPeers := []*AnchorPeer{}
+		if err := json.Unmarshal(item.Value, &anchorPeers); err != nil {
+			return nil, err
+		}
+		return anchorPeers, nil
+	}
+	return []*AnchorPeer{}, nil
+}
+
+// blockIsConfig reports whether the block's first transaction is a configuration transaction.
+func blockIsConfig(block *common.Block) (bool, error) {
+	if len(block.Data.Data) == 0 {
+		return false, nil
+	}
+	payload, err := getTxPayload(block.Data.Data[0])
+	if err != nil {
+		return false, err
+	}
+	return payload.Header != nil && payload.Header.ChainHeader != nil &&
+		common.HeaderType(payload.Header.ChainHeader.Type) == common.HeaderType_CONFIGURATION_TRANSACTION, nil
+}
+
+// TxValidationCode is the outcome recorded for a transaction's position in a block's
+// TRANSACTIONS_FILTER metadata. This ledger's validator (statebasedval.Validator) records only a
+// single bit per transaction - set means the transaction failed MVCC validation, unset means it
+// committed - so TxValid and TxValidationCodeMVCCReadConflict are the only two codes this snapshot
+// can actually produce or distinguish.
+type TxValidationCode int
+
+const (
+	// TxValid marks a transaction whose writes were applied to the state.
+	TxValid TxValidationCode = iota
+	// TxValidationCodeMVCCReadConflict marks a transaction skipped by the validator because a key
+	// it read was concurrently modified within the same block.
+	TxValidationCodeMVCCReadConflict
+)
+
+// ValidationResult pairs a transaction ID with the block it was found in.
+type ValidationResult struct {
+	TxID        string
+	BlockNumber uint64
+}
+
+// GetTransactionsByValidationCode returns, for debugging endorsement failures, the transactions
+// across [startBlockNumber, endBlockNumber] (inclusive) whose recorded validation outcome matches
+// code. Returns ErrBlockRangeTooLarge if the range spans more than maxBlockRangeSize blocks.
+func (l *KVLedger) GetTransactionsByValidationCode(startBlockNumber, endBlockNumber uint64, code TxValidationCode) ([]*ValidationResult, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+	var results []*ValidationResult
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		txsFilter := ledgerutil.NewFilterBitArrayFromBytes(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+		for txIndex, envBytes := range block.Data.Data {
+			txCode := TxValid
+			if txsFilter.IsSet(uint(txIndex)) {
+				txCode = TxValidationCodeMVCCReadConflict
+			}
+			if txCode != code {
+				continue
+			}
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			if payload.Header == nil || payload.Header.ChainHeader == nil {
+				continue
+			}
+			results = append(results, &ValidationResult{TxID: payload.Header.ChainHeader.TxID, BlockNumber: blockNumber})
+		}
+	}
+	return results, nil
+}
+
+// ValidationFailureFilter narrows WatchValidationFailures to a specific validation code and/or
+// chaincode. The zero value delivers every invalidated transaction: HasCode must be set to restrict
+// by Code, since TxValid (the zero TxValidationCode) is itself a meaningful code and can't double as
+// an "unset" marker.
+type ValidationFailureFilter struct {
+	Code        TxValidationCode
+	HasCode     bool
+	ChaincodeID string
+}
+
+// WatchValidationFailures streams a ValidationResult for every invalidated transaction as blocks
+// commit, starting at startBlockNumber (inclusive) and continuing to tail the chain head the same
+// way StreamBlocks does, rather than stopping once the current height is reached. A non-nil filter
+// restricts delivered events to a specific validation code (filter.HasCode) and/or chaincode
+// (filter.ChaincodeID); the zero filter (or a nil one) delivers every invalidated transaction.
+// Closing cancel unblocks the underlying iterator and causes WatchValidationFailures to return nil,
+// just like StreamBlocks.
+func (l *KVLedger) WatchValidationFailures(startBlockNumber uint64, filter *ValidationFailureFilter, listener func(*ValidationResult) error, cancel <-chan struct{}) error {
+	return l.StreamBlocks(startBlockNumber, func(block *common.Block) error {
+		txsFilter := ledgerutil.NewFilterBitArrayFromBytes(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+		for txIndex, envBytes := range block.Data.Data {
+			if !txsFilter.IsSet(uint(txIndex)) {
+				continue
+			}
+			txCode := TxValidationCodeMVCCReadConflict
+			if filter != nil && filter.HasCode && filter.Code != txCode {
+				continue
+			}
+			if filter != nil && filter.ChaincodeID != "" {
+				invokes, err := envelopeInvokesChaincode(envBytes, filter.ChaincodeID)
+				if err != nil {
+					return err
+				}
+				if !invokes {
+					continue
+				}
+			}
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return err
+			}
+			if payload.Header == nil || payload.Header.ChainHeader == nil {
+				continue
+			}
+			if err := listener(&ValidationResult{TxID: payload.Header.ChainHeader.TxID, BlockNumber: block.Header.Number}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, cancel)
+}
+
+// DuplicateTransaction reports a transaction ID that was found more than once within a scanned
+// range, together with every block number it appeared in.
+type DuplicateTransaction struct {
+	TxID         string
+	BlockNumbers []uint64
+}
+
+// FindDuplicateTransactions scans [startBlockNumber, endBlockNumber] (inclusive) for transaction IDs
+// that appear in more than one block, a signature of a replay attack or an indexing bug, and reports
+// each one with the block numbers it was found in. Returns ErrBlockRangeTooLarge if the range spans
+// more than maxBlockRangeSize blocks.
+func (l *KVLedger) FindDuplicateTransactions(startBlockNumber, endBlockNumber uint64) ([]*DuplicateTransaction, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+	blockNumbersByTxID := map[string][]uint64{}
+	var txIDOrder []string
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, envBytes := range block.Data.Data {
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			if payload.Header == nil || payload.Header.ChainHeader == nil {
+				continue
+			}
+			txID := payload.Header.ChainHeader.TxID
+			if _, seen := blockNumbersByTxID[txID]; !seen {
+				txIDOrder = append(txIDOrder, txID)
+			}
+			blockNumbersByTxID[txID] = append(blockNumbersByTxID[txID], blockNumber)
+		}
+	}
+	var duplicates []*DuplicateTransaction
+	for _, txID := range txIDOrder {
+		if blockNumbers := blockNumbersByTxID[txID]; len(blockNumbers) > 1 {
+			duplicates = append(duplicates, &DuplicateTransaction{TxID: txID, BlockNumbers: blockNumbers})
+		}
+	}
+	return duplicates, nil
+}
+
+// GetChaincodeTxDistribution returns, for usage dashboards, the number of transactions targeting
+// each chaincode across [startBlockNumber, endBlockNumber] (inclusive). Returns
+// ErrBlockRangeTooLarge if the range spans more than maxBlockRangeSize blocks.
+func (l *KVLedger) GetChaincodeTxDistribution(startBlockNumber, endBlockNumber uint64) (map[string]uint64, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+	distribution := map[string]uint64{}
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, envBytes := range block.Data.Data {
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			hdrExt, err := utils.GetChaincodeHeaderExtension(payload.Header)
+			if err != nil {
+				// not an endorser transaction (e.g., a configuration transaction); no chaincode to attribute it to
+				continue
+			}
+			if hdrExt.ChaincodeID == nil {
+				continue
+			}
+			distribution[hdrExt.ChaincodeID.Name]++
+		}
+	}
+	return distribution, nil
+}
+
+// ChaincodeActivity reports how many times a chaincode was invoked within a recent window of blocks.
+type ChaincodeActivity struct {
+	ChaincodeID     string
+	InvocationCount uint64
+}
+
+// GetActiveChaincodes returns the chaincodes invoked within the last lastNBlocks blocks of the chain,
+// each with its invocation count over that window, ordered most active first and by chaincode id
+// among ties, for a "trending chaincodes" view. A lastNBlocks of zero, or one larger than the chain's
+// height, is treated as "the whole chain". Returns ErrEmptyBlockchain if the chain has no blocks.
+func (l *KVLedger) GetActiveChaincodes(lastNBlocks uint64) ([]*ChaincodeActivity, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info.Height == 0 {
+		return nil, ErrEmptyBlockchain
+	}
+	startBlockNumber := uint64(1)
+	if lastNBlocks > 0 && info.Height > lastNBlocks {
+		startBlockNumber = info.Height - lastNBlocks + 1
+	}
+	counts, err := l.GetChaincodeTxDistribution(startBlockNumber, info.Height)
+	if err != nil {
+		return nil, err
+	}
+	activity := make([]*ChaincodeActivity, 0, len(counts))
+	for chaincodeID, count := range counts {
+		activity = append(activity, &ChaincodeActivity{ChaincodeID: chaincodeID, InvocationCount: count})
+	}
+	sort.Slice(activity, func(i, j int) bool {
+		if activity[i].InvocationCount != activity[j].InvocationCount {
+			return activity[i].InvocationCount > activity[j].InvocationCount
+		}
+		return activity[i].ChaincodeID < activity[j].ChaincodeID
+	})
+	return activity, nil
+}
+
+// GetTxCountByOrg returns, for org-level reporting, the number of transactions submitted by each
+// organization across [startBlockNumber, endBlockNumber] (inclusive), keyed by the submitter's MSP
+// id recovered from the transaction's creator. A transaction whose creator cannot be parsed as a
+// serialized identity is attributed to "unknown" rather than dropped, since this snapshot does not
+// validate creators against a configured MSP the way a full peer would. Returns
+// ErrBlockRangeTooLarge if the range spans more than maxBlockRangeSize blocks.
+func (l *KVLedger) GetTxCountByOrg(startBlockNumber, endBlockNumber uint64) (map[string]uint64, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+	counts := map[string]uint64{}
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, envBytes := range block.Data.Data {
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			counts[creatorMspID(payload.Header)]++
+		}
+	}
+	return counts, nil
+}
+
+// creatorMspID recovers the MSP id of the identity that created a transaction, from the serialized
+// identity bytes carried in its signature header. Returns "unknown" when the header is missing its
+// signature header or the creator bytes do not parse as a serialized identity.
+func creatorMspID(header *common.Header) string {
+	if header == nil || header.SignatureHeader == nil {
+		return "unknown"
+	}
+	identity := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(header.SignatureHeader.Creator, identity); err != nil || identity.Mspid == "" {
+		return "unknown"
+	}
+	return identity.Mspid
+}
+
+// endorserMspID recovers the MSP id of an endorsement's signer from its serialized identity bytes,
+// using the same best-effort recovery as creatorMspID.
+func endorserMspID(endorsement *pb.Endorsement) string {
+	if endorsement == nil {
+		return "unknown"
+	}
+	identity := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(endorsement.Endorser, identity); err != nil || identity.Mspid == "" {
+		return "unknown"
+	}
+	return identity.Mspid
+}
+
+// GetTransactionsEndorsedByOrg returns the ids of transactions in [startBlockNumber,
+// endBlockNumber] (inclusive) endorsed by at least one peer belonging to mspID, recovered from each
+// endorsement's serialized identity the same way creatorMspID recovers a transaction's submitter.
+// Returns ErrBlockRangeTooLarge if the range spans more than maxBlockRangeSize blocks.
+func (l *KVLedger) GetTransactionsEndorsedByOrg(startBlockNumber, endBlockNumber uint64, mspID string) ([]string, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+	var txIDs []string
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, envBytes := range block.Data.Data {
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			if common.HeaderType(payload.Header.ChainHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+				continue
+			}
+			tx, err := utils.GetTransaction(payload.Data)
+			if err != nil {
+				return nil, err
+			}
+			endorsedByOrg := false
+			for _, action := range tx.Actions {
+				actionPayload, err := utils.GetChaincodeActionPayload(action.Payload)
+				if err != nil {
+					return nil, err
+				}
+				for _, endorsement := range actionPayload.Action.Endorsements {
+					if endorserMspID(endorsement) == mspID {
+						endorsedByOrg = true
+						break
+					}
+				}
+			}
+			if endorsedByOrg {
+				txIDs = append(txIDs, payload.Header.ChainHeader.TxID)
+			}
+		}
+	}
+	return txIDs, nil
+}
+
+// CountStateDeletes returns, for churn monitoring, the number of delete operations recorded in
+// transactions' write-sets across [startBlockNumber, endBlockNumber] (inclusive). Returns
+// ErrBlockRangeTooLarge if the range spans more than maxBlockRangeSize blocks.
+func (l *KVLedger) CountStateDeletes(startBlockNumber, endBlockNumber uint64) (uint64, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return 0, err
+	}
+	var count uint64
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return 0, err
+		}
+		for _, envBytes := range block.Data.Data {
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return 0, err
+			}
+			hdrExt, err := utils.GetChaincodeHeaderExtension(payload.Header)
+			if err != nil || hdrExt.ChaincodeID == nil {
+				// not an endorser transaction (e.g., a configuration transaction); no write-set to scan
+				continue
+			}
+			action, err := utils.GetActionFromEnvelope(envBytes)
+			if err != nil {
+				return 0, err
+			}
+			txRWSet := &rwset.TxReadWriteSet{}
+			if err := txRWSet.Unmarshal(action.Results); err != nil {
+				return 0, err
+			}
+			for _, nsRW := range txRWSet.NsRWs {
+				for _, write := range nsRW.Writes {
+					if write.IsDelete {
+						count++
+					}
+				}
+			}
+		}
+	}
+	return count, nil
+}
+
+// GetReadOnlyChaincodes identifies the chaincodes whose transactions in the given block range only
+// read state and never write it, for access-pattern analysis. A chaincode with zero transactions in
+// the range is not included. Returns chaincodes in the order their first transaction was seen.
+func (l *KVLedger) GetReadOnlyChaincodes(startBlockNumber, endBlockNumber uint64) ([]string, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+	hasWrite := map[string]bool{}
+	var chaincodeOrder []string
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, envBytes := range block.Data.Data {
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			hdrExt, err := utils.GetChaincodeHeaderExtension(payload.Header)
+			if err != nil || hdrExt.ChaincodeID == nil {
+				// not an endorser transaction (e.g., a configuration transaction); no chaincode to attribute it to
+				continue
+			}
+			ccName := hdrExt.ChaincodeID.Name
+			if _, seen := hasWrite[ccName]; !seen {
+				hasWrite[ccName] = false
+				chaincodeOrder = append(chaincodeOrder, ccName)
+			}
+			if hasWrite[ccName] {
+				continue
+			}
+			action, err := utils.GetActionFromEnvelope(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			txRWSet := &rwset.TxReadWriteSet{}
+			if err := txRWSet.Unmarshal(action.Results); err != nil {
+				return nil, err
+			}
+			for _, nsRW := range txRWSet.NsRWs {
+				if len(nsRW.Writes) > 0 {
+					hasWrite[ccName] = true
+					break
+				}
+			}
+		}
+	}
+	readOnly := []string{}
+	for _, ccName := range chaincodeOrder {
+		if !hasWrite[ccName] {
+			readOnly = append(readOnly, ccName)
+		}
+	}
+	return readOnly, nil
+}
+
+// GetTxTypeCounts tallies the transactions in the given block range into "deploy" (an endorser
+// transaction invoking the lccc lifecycle chaincode), "invoke" (any other endorser transaction),
+// "config" (a configuration transaction), or "other" (any other header type), so callers can
+// characterize a chain's mix of activity.
+func (l *KVLedger) GetTxTypeCounts(startBlockNumber, endBlockNumber uint64) (map[string]uint64, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+	counts := map[string]uint64{}
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, envBytes := range block.Data.Data {
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			switch common.HeaderType(payload.Header.ChainHeader.Type) {
+			case common.HeaderType_CONFIGURATION_TRANSACTION:
+				counts["config"]++
+			case common.HeaderType_ENDORSER_TRANSACTION:
+				isDeploy, err := envelopeInvokesChaincode(envBytes, lcccName)
+				if err != nil {
+					return nil, err
+				}
+				if isDeploy {
+					counts["deploy"]++
+				} else {
+					counts["invoke"]++
+				}
+			default:
+				counts["other"]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// txCountInRange returns the total number of transactions, of any type, in
+// [startBlockNumber, endBlockNumber] (inclusive). Callers are responsible for calling
+// checkBlockRange first.
+func (l *KVLedger) txCountInRange(startBlockNumber, endBlockNumber uint64) (uint64, error) {
+	var count uint64
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return 0, err
+		}
+		count += uint64(len(block.Data.Data))
+	}
+	return count, nil
+}
+
+// RangeTxCountComparison reports the transaction counts of two block ranges and their difference
+// (FirstRangeCount - SecondRangeCount), for dashboards comparing activity across two periods.
+type RangeTxCountComparison struct {
+	FirstRangeCount  uint64
+	SecondRangeCount uint64
+	Difference       int64
+}
+
+// CompareRangeTxCounts returns the transaction counts of [firstStart, firstEnd] and
+// [secondStart, secondEnd] and their difference. Each range is independently capped at
+// maxBlockRangeSize via checkBlockRange.
+func (l *KVLedger) CompareRangeTxCounts(firstStart, firstEnd, secondStart, secondEnd uint64) (*RangeTxCountComparison, error) {
+	if err := checkBlockRange(firstStart, firstEnd); err != nil {
+		return nil, err
+	}
+	if err := checkBlockRange(secondStart, secondEnd); err != nil {
+		return nil, err
+	}
+	firstCount, err := l.txCountInRange(firstStart, firstEnd)
+	if err != nil {
+		return nil, err
+	}
+	secondCount, err := l.txCountInRange(secondStart, secondEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &RangeTxCountComparison{
+		FirstRangeCount:  firstCount,
+		SecondRangeCount: secondCount,
+		Difference:       int64(firstCount) - int64(secondCount),
+	}, nil
+}
+
+// GetBlockchainInfo returns basic info about blockchain
+func (l *KVLedger) GetBlockchainInfo() (*pb.BlockchainInfo, error) {
+	return l.blockStore.GetBlockchainInfo()
+}
+
+// GetBlockByNumber returns block at a given height
+// blockNumber of  math.MaxUint64 will return last block
+func (l *KVLedger) GetBlockByNumber(blockNumber uint64) (*common.Block, error) {
+	return l.blockStore.RetrieveBlockByNumber(blockNumber)
+
+}
+
+// GetBlockHash returns the hash of the block header at the given block number, without requiring the
+// caller to fetch and marshal the entire block. Returns ledger.ErrNotFound if the block does not exist.
+func (l *KVLedger) GetBlockHash(blockNumber uint64) ([]byte, error) {
+	block, err := l.GetBlockByNumber(blockNumber)
+	if err != nil {
+		return nil, ledger.ErrNotFound
+	}
+	return block.Header.Hash(), nil
+}
+
+// CompressedBlock carries a block's serialized bytes gzip-compressed, with the original size so the
+// caller can preallocate a buffer before decompressing.
+type CompressedBlock struct {
+	Data         []byte
+	OriginalSize int
+}
+
+// GetCompressedBlock returns the block at the given block number, marshaled and gzip-compressed, for
+// bandwidth-constrained clients that cannot negotiate transport-level compression. Returns
+// ledger.ErrNotFound if the block does not exist.
+func (l *KVLedger) GetCompressedBlock(blockNumber uint64) (*CompressedBlock, error) {
+	block, err := l.GetBlockByNumber(blockNumber)
+	if err != nil {
+		return nil, ledger.ErrNotFound
+	}
+	blockBytes, err := proto.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	var compressed bytes.Buffer
+	gzWriter := gzip.NewWriter(&compressed)
+	if _, err := gzWriter.Write(blockBytes); err != nil {
+		return nil, err
+	}
+	if err := gzWriter.Close(); err != nil {
+		return nil, err
+	}
+	return &CompressedBlock{Data: compressed.Bytes(), OriginalSize: len(blockBytes)}, nil
+}
+
+// GetBlockTimestamp returns the commit timestamp of the block at the given block number, without
+// requiring the caller to fetch and inspect the entire block. Returns ledger.ErrNotFound if the
+// block does not exist.
+func (l *KVLedger) GetBlockTimestamp(blockNumber uint64) (*timestamp.Timestamp, error) {
+	block, err := l.GetBlockByNumber(blockNumber)
+	if err != nil {
+		return nil, ledger.ErrNotFound
+	}
+	return firstTxTimestamp(block)
+}
+
+// CompareBlockHash reports whether peerHash, presumably obtained out-of-band from another peer,
+// matches this ledger's block header hash at blockNumber. This peer has no client for fetching
+// another peer's block hash over the wire, so the caller remains responsible for supplying
+// peerHash; this call only performs the local half of the comparison. Returns ledger.ErrNotFound
+// if this ledger does not have a block at blockNumber.
+func (l *KVLedger) CompareBlockHash(blockNumber uint64, peerHash []byte) (bool, error) {
+	localHash, err := l.GetBlockHash(blockNumber)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(localHash, peerHash), nil
+}
+
+// PeerTipSource reports another peer's current chain height and its hash for a given block number,
+// so PeersAgreeOnTip can compare this peer's tip against others. This snapshot has no peer-to-peer
+// query RPC for fetching a fellow peer's ledger state (core/comm only wires client connections for
+// specific, already-defined services such as the orderer's AtomicBroadcast), so callers supply an
+// implementation backed by whatever channel they use to reach their peers.
+type PeerTipSource interface {
+	// PeerID identifies the peer, for reporting in AgreementResult.Dissenters
+	PeerID() string
+	Height() (uint64, error)
+	BlockHash(blockNumber uint64) ([]byte, error)
+}
+
+// AgreementResult reports whether this ledger and a set of peers agree on the block hash at their
+// common minimum height.
+type AgreementResult struct {
+	Agree      bool
+	CheckedAt  uint64
+	Dissenters []string
+}
+
+// PeersAgreeOnTip queries peers for their current block hash at the common minimum height shared by
+// this ledger and all of them, and reports whether everyone agrees, listing any dissenters. Returns
+// ErrEmptyBlockchain if the common minimum height is zero.
+func (l *KVLedger) PeersAgreeOnTip(peers []PeerTipSource) (*AgreementResult, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	minHeight := info.Height
+	for _, p := range peers {
+		height, err := p.Height()
+		if err != nil {
+			return nil, err
+		}
+		if height < minHeight {
+			minHeight = height
+		}
+	}
+	if minHeight == 0 {
+		return nil, ErrEmptyBlockchain
+	}
+	commonBlockNumber := minHeight
+	localHash, err := l.GetBlockHash(commonBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	result := &AgreementResult{Agree: true, CheckedAt: commonBlockNumber}
+	for _, p := range peers {
+		hash, err := p.BlockHash(commonBlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(hash, localHash) {
+			result.Agree = false
+			result.Dissenters = append(result.Dissenters, p.PeerID())
+		}
+	}
+	return result, nil
+}
+
+// LaggingPeer identifies a peer whose height fell more than a query's threshold behind the maximum
+// height seen across this ledger and the queried peers.
+type LaggingPeer struct {
+	PeerID string
+	Height uint64
+}
+
+// GetLaggingPeers queries peers (alongside this ledger's own height) and returns those more than
+// threshold blocks behind the maximum height found, so operators can spot stragglers needing
+// intervention.
+func (l *KVLedger) GetLaggingPeers(peers []PeerTipSource, threshold uint64) ([]*LaggingPeer, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	heights := make(map[string]uint64, len(peers))
+	maxHeight := info.Height
+	for _, p := range peers {
+		height, err := p.Height()
+		if err != nil {
+			return nil, err
+		}
+		heights[p.PeerID()] = height
+		if height > maxHeight {
+			maxHeight = height
+		}
+	}
+	var lagging []*LaggingPeer
+	for _, p := range peers {
+		height := heights[p.PeerID()]
+		if maxHeight-height > threshold {
+			lagging = append(lagging, &LaggingPeer{PeerID: p.PeerID(), Height: height})
+		}
+	}
+	return lagging, nil
+}
+
+// PeerEndpoint identifies a peer for client routing purposes.
+type PeerEndpoint struct {
+	PeerID string
+	Height uint64
+}
+
+// GetBestValidator queries peers concurrently for their height via PeerTipSource.Height, measuring
+// each one's response latency, and returns the endpoint of the fastest responding peer whose height
+// is within staleness blocks of the maximum height seen, so a smart client can route to a peer that
+// is both responsive and reasonably caught up. A peer whose Height call errors is excluded from
+// consideration. Returns ledger.ErrNotFound if no peer qualifies.
+func (l *KVLedger) GetBestValidator(peers []PeerTipSource, staleness uint64) (*PeerEndpoint, error) {
+	type probe struct {
+		peer    PeerTipSource
+		height  uint64
+		latency time.Duration
+		err     error
+	}
+	results := make(chan probe, len(peers))
+	for _, p := range peers {
+		go func(p PeerTipSource) {
+			start := time.Now()
+			height, err := p.Height()
+			results <- probe{peer: p, height: height, latency: time.Since(start), err: err}
+		}(p)
+	}
+	probes := make([]probe, 0, len(peers))
+	for i := 0; i < len(peers); i++ {
+		probes = append(probes, <-results)
+	}
+	var maxHeight uint64
+	for _, pr := range probes {
+		if pr.err == nil && pr.height > maxHeight {
+			maxHeight = pr.height
+		}
+	}
+	var best *probe
+	for i := range probes {
+		pr := &probes[i]
+		if pr.err != nil || maxHeight-pr.height > staleness {
+			continue
+		}
+		if best == nil || pr.latency < best.latency {
+			best = pr
+		}
+	}
+	if best == nil {
+		return nil, ledger.ErrNotFound
+	}
+	return &PeerEndpoint{PeerID: best.peer.PeerID(), Height: best.height}, nil
+}
+
+// ErrFullChainVerificationDisabled is returned by VerifyFullChain when
+// ledgerconfig.IsFullChainVerificationEnabled reports the sweep has not been enabled. Re-hashing
+// every block on a long chain is expensive enough that it should not run unattended by default.
+var ErrFullChainVerificationDisabled = errors.New("Full chain verification is disabled")
+
+// ChainVerifyResult reports the outcome of a VerifyFullChain integrity sweep. BrokenAtBlock is zero
+// when Valid is true.
+type ChainVerifyResult struct {
+	Valid          bool
+	BlocksVerified uint64
+	BrokenAtBlock  uint64
+}
+
+// VerifyFullChain walks the chain from genesis to the current tip, verifying for each block that
+// its PreviousHash correctly links to the preceding block's header hash and that its DataHash
+// matches a fresh hash of its stored transaction data. It stops and reports the first block that
+// fails either check, rather than continuing to scan a chain already known to be broken. Guarded
+// behind ledgerconfig.IsFullChainVerificationEnabled since the rehash is expensive on a long chain.
+func (l *KVLedger) VerifyFullChain() (*ChainVerifyResult, error) {
+	if !ledgerconfig.IsFullChainVerificationEnabled() {
+		return nil, ErrFullChainVerificationDisabled
+	}
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	var previousHash []byte
+	var blocksVerified uint64
+	for blockNumber := uint64(1); blockNumber <= info.Height; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if blockNumber > 1 && !bytes.Equal(block.Header.PreviousHash, previousHash) {
+			return &ChainVerifyResult{BlocksVerified: blocksVerified, BrokenAtBlock: blockNumber}, nil
+		}
+		if !bytes.Equal(block.Header.DataHash, block.Data.Hash()) {
+			return &ChainVerifyResult{BlocksVerified: blocksVerified, BrokenAtBlock: blockNumber}, nil
+		}
+		previousHash = block.Header.Hash()
+		blocksVerified++
+	}
+	return &ChainVerifyResult{Valid: true, BlocksVerified: blocksVerified}, nil
+}
+
+// ForkCheckResult reports whether the chain contains a fork.
+type ForkCheckResult struct {
+	Forked         bool
+	ForkedAtHeight uint64
+}
+
+// HasForks performs a one-shot integrity check for forks in the chain's history. This snapshot's
+// BlockStore does not record sibling blocks at a given height (it is append-only and keeps exactly
+// one block per height), so the only way a fork could manifest here is a broken PreviousHash
+// linkage: a block whose PreviousHash does not match the preceding block's header hash. HasForks
+// walks the chain checking that linkage and reports the first height at which it breaks, if any.
+func (l *KVLedger) HasForks() (*ForkCheckResult, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	var previousHash []byte
+	for blockNumber := uint64(1); blockNumber <= info.Height; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if blockNumber > 1 && !bytes.Equal(block.Header.PreviousHash, previousHash) {
+			return &ForkCheckResult{Forked: true, ForkedAtHeight: blockNumber}, nil
+		}
+		previousHash = block.Header.Hash()
+	}
+	return &ForkCheckResult{}, nil
+}
+
+// ChainStatistics reports aggregate statistics over the entire chain, suitable for a dashboard view
+type ChainStatistics struct {
+	Height             uint64
+	TotalTransactions  uint64
+	DistinctChaincodes uint64
+	DistinctIdentities uint64
+	AverageBlockSizeKB float64
+	ChainAge           time.Duration
+}
+
+// GetChainStatistics returns aggregate statistics over the entire chain. This consolidates several
+// individual endpoints into a single call convenient for dashboards.
+func (l *KVLedger) GetChainStatistics() (*ChainStatistics, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	stats := &ChainStatistics{Height: info.Height}
+	if info.Height == 0 {
+		return stats, nil
+	}
+
+	chaincodes := map[string]bool{}
+	identities := map[string]bool{}
+	var totalBytes uint64
+	var firstTimestamp, lastTimestamp *timestamp.Timestamp
+
+	for blockNumber := uint64(1); blockNumber <= info.Height; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		blockBytes, err := proto.Marshal(block)
+		if err != nil {
+			return nil, err
+		}
+		totalBytes += uint64(len(blockBytes))
+		for _, envBytes := range block.Data.Data {
+			payload, err := getTxPayload(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			stats.TotalTransactions++
+			identities[string(payload.Header.SignatureHeader.Creator)] = true
+			if payload.Header.ChainHeader.Timestamp != nil {
+				if firstTimestamp == nil {
+					firstTimestamp = payload.Header.ChainHeader.Timestamp
+				}
+				lastTimestamp = payload.Header.ChainHeader.Timestamp
+			}
+			if ccHdrExt, err := utils.GetChaincodeHeaderExtension(payload.Header); err == nil && ccHdrExt.ChaincodeID != nil {
+				chaincodes[ccHdrExt.ChaincodeID.Name] = true
+			}
+		}
+	}
+
+	stats.DistinctChaincodes = uint64(len(chaincodes))
+	stats.DistinctIdentities = uint64(len(identities))
+	stats.AverageBlockSizeKB = float64(totalBytes) / float64(info.Height) / 1024
+	if firstTimestamp != nil && lastTimestamp != nil {
+		first, err := ptypes.Timestamp(firstTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		last, err := ptypes.Timestamp(lastTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		stats.ChainAge = last.Sub(first)
+	}
+	return stats, nil
+}
+
+// StorageBreakdown reports how a ledger's on-disk footprint divides across its constituent stores.
+// BlockStoreBytes is always populated, since the block store is always filesystem-backed. The
+// remaining fields are populated only for the filesystem-backed state database (goleveldb); when a
+// store is backed by something else (e.g. CouchDB) its *Unsupported flag is set and its byte count
+// is left at zero, since this ledger has no visibility into that store's on-disk usage.
+type StorageBreakdown struct {
+	BlockStoreBytes      uint64
+	StateDBBytes         uint64
+	StateDBUnsupported   bool
+	HistoryDBBytes       uint64
+	HistoryDBUnsupported bool
+}
+
+// dirSize returns the total size, in bytes, of the regular files under dir. Missing directories
+// contribute zero rather than an error, since a store that has not yet written anything to disk is
+// not a failure.
+func dirSize(dir string) (uint64, error) {
+	var total uint64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			total += uint64(info.Size())
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetStorageBreakdown returns the on-disk byte footprint of the ledger's block store, state
+// database, and history index, separately, so operators can see where space goes. The state and
+// history databases are reported as unsupported when they are not filesystem-backed (e.g. CouchDB),
+// in which case their byte counts are zero.
+func (l *KVLedger) GetStorageBreakdown() (*StorageBreakdown, error) {
+	breakdown := &StorageBreakdown{}
+
+	blockStoreBytes, err := dirSize(l.conf.blockStorageDir)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.BlockStoreBytes = blockStoreBytes
+
+	if ledgerconfig.IsCouchDBEnabled() {
+		breakdown.StateDBUnsupported = true
+	} else {
+		stateDBBytes, err := dirSize(l.conf.txMgrDBPath)
+		if err != nil {
+			return nil, err
+		}
+		breakdown.StateDBBytes = stateDBBytes
+	}
+
+	// The history index, when enabled, is CouchDB-backed with no filesystem path this ledger
+	// tracks, so its size is never directly observable here.
+	breakdown.HistoryDBUnsupported = true
+
+	return breakdown, nil
+}
+
+// RateBucket reports how many blocks and transactions were committed within a single time interval,
+// for commit-rate trend analysis.
+type RateBucket struct {
+	IntervalStart         time.Time
+	BlocksCommitted       uint64
+	TransactionsCommitted uint64
+}
+
+// GetCommitRateTrend buckets blocks in [startBlockNumber, endBlockNumber] into consecutive
+// intervals of bucketDuration, keyed by each block's commit timestamp (taken from its first
+// transaction, as with VerifyCommitOrder), and reports the blocks-committed and
+// transactions-committed counts per bucket, for capacity forecasting. Buckets are returned in
+// chronological order; a block with no timestamped transaction is skipped. Returns
+// ErrBlockRangeTooLarge if the range spans more than maxBlockRangeSize blocks.
+func (l *KVLedger) GetCommitRateTrend(startBlockNumber, endBlockNumber uint64, bucketDuration time.Duration) ([]*RateBucket, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+	if bucketDuration <= 0 {
+		return nil, fmt.Errorf("bucketDuration must be positive")
+	}
+
+	var order []time.Time
+	buckets := map[time.Time]*RateBucket{}
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		blockTimestamp, err := firstTxTimestamp(block)
+		if err != nil {
+			return nil, err
+		}
+		if blockTimestamp == nil {
+			continue
+		}
+		commitTime, err := ptypes.Timestamp(blockTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		intervalStart := commitTime.Truncate(bucketDuration)
+		bucket, ok := buckets[intervalStart]
+		if !ok {
+			bucket = &RateBucket{IntervalStart: intervalStart}
+			buckets[intervalStart] = bucket
+			order = append(order, intervalStart)
+		}
+		bucket.BlocksCommitted++
+		bucket.TransactionsCommitted += uint64(len(block.Data.Data))
+	}
+
+	sort.Slice(order, func(i, j int) bool { return order[i].Before(order[j]) })
+	trend := make([]*RateBucket, 0, len(order))
+	for _, intervalStart := range order {
+		trend = append(trend, buckets[intervalStart])
+	}
+	return trend, nil
+}
+
+// BlockBurst reports a run of consecutive blocks whose commit timestamps fall within the same burst
+// window, useful for spotting load spikes.
+type BlockBurst struct {
+	StartBlockNumber uint64
+	EndBlockNumber   uint64
+	BlockCount       uint64
+}
+
+// FindBlockBursts scans [startBlockNumber, endBlockNumber] and returns the maximal runs of two or
+// more consecutive blocks whose commit timestamps (taken from each block's first transaction, as
+// with VerifyCommitOrder) fall within the same window duration, for spotting load spikes. A block
+// with no timestamped transaction ends any run in progress. Returns ErrBlockRangeTooLarge if the
+// range spans more than maxBlockRangeSize blocks.
+func (l *KVLedger) FindBlockBursts(startBlockNumber, endBlockNumber uint64, window time.Duration) ([]*BlockBurst, error) {
+	if err := checkBlockRange(startBlockNumber, endBlockNumber); err != nil {
+		return nil, err
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be positive")
+	}
+
+	var bursts []*BlockBurst
+	var runStart uint64
+	var runCount uint64
+	var windowStart time.Time
+
+	flush := func() {
+		if runCount >= 2 {
+			bursts = append(bursts, &BlockBurst{StartBlockNumber: runStart, EndBlockNumber: runStart + runCount - 1, BlockCount: runCount})
+		}
+		runCount = 0
+	}
+
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		blockTimestamp, err := firstTxTimestamp(block)
+		if err != nil {
+			return nil, err
+		}
+		if blockTimestamp == nil {
+			flush()
+			continue
+		}
+		commitTime, err := ptypes.Timestamp(blockTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		if runCount == 0 || commitTime.Sub(windowStart) >= window {
+			flush()
+			runStart = blockNumber
+			windowStart = commitTime
+		}
+		runCount++
+	}
+	flush()
+	return bursts, nil
+}
+
+// GetLatestBlockTxIDs returns the transaction IDs contained in the most recently committed block, without
+// their bodies, so that a poller can cheaply diff against what it has already seen.
+func (l *KVLedger) GetLatestBlockTxIDs() ([]string, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info.Height == 0 {
+		return nil, ErrEmptyBlockchain
+	}
+	block, err := l.GetBlockByNumber(math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+	txIDs := make([]string, 0, len(block.Data.Data))
+	for _, envBytes := range block.Data.Data {
+		payload, err := getTxPayload(envBytes)
+		if err != nil {
+			return nil, err
+		}
+		txIDs = append(txIDs, payload.Header.ChainHeader.TxID)
+	}
+	return txIDs, nil
+}
+
+// maxCommitOrderRange caps the number of blocks VerifyCommitOrder will walk in a single call
+const maxCommitOrderRange = 10000
+
+// OrderAnomaly describes the first block at which commit ordering was found to be violated
+type OrderAnomaly struct {
+	BlockNumber uint64
+	Reason      string
+}
+
+// VerifyCommitOrder checks that block numbers in [startBlockNumber, endBlockNumber] are strictly
+// increasing by one and that block timestamps (taken from each block's first transaction) are
+// monotonically non-decreasing, flagging the first anomaly found. The range is capped at
+// maxCommitOrderRange blocks.
+func (l *KVLedger) VerifyCommitOrder(startBlockNumber, endBlockNumber uint64) (*OrderAnomaly, error) {
+	if endBlockNumber < startBlockNumber {
+		return nil, fmt.Errorf("endBlockNumber %d is less than startBlockNumber %d", endBlockNumber, startBlockNumber)
+	}
+	if endBlockNumber-startBlockNumber+1 > maxCommitOrderRange {
+		return nil, fmt.Errorf("requested range of %d blocks exceeds the maximum of %d", endBlockNumber-startBlockNumber+1, maxCommitOrderRange)
+	}
+
+	var prevBlock *common.Block
+	var prevTimestamp *timestamp.Timestamp
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if prevBlock != nil && block.Header.Number != prevBlock.Header.Number+1 {
+			return &OrderAnomaly{BlockNumber: block.Header.Number,
+				Reason: fmt.Sprintf("block number %d does not immediately follow %d", block.Header.Number, prevBlock.Header.Number)}, nil
+		}
+		blockTimestamp, err := firstTxTimestamp(block)
+		if err != nil {
+			return nil, err
+		}
+		if prevTimestamp != nil && blockTimestamp != nil {
+			prevTime, err := ptypes.Timestamp(prevTimestamp)
+			if err != nil {
+				return nil, err
+			}
+			currTime, err := ptypes.Timestamp(blockTimestamp)
+			if err != nil {
+				return nil, err
+			}
+			if currTime.Before(prevTime) {
+				return &OrderAnomaly{BlockNumber: block.Header.Number,
+					Reason: fmt.Sprintf("block timestamp %s precedes previous block timestamp %s", currTime, prevTime)}, nil
+			}
+		}
+		prevBlock = block
+		if blockTimestamp != nil {
+			prevTimestamp = blockTimestamp
+		}
+	}
+	return nil, nil
+}
+
+// getTxPayload unmarshals the envelope bytes stored in a block and returns the enclosed payload
+func getTxPayload(envBytes []byte) (*common.Payload, error) {
+	env, err := utils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return nil, err
+	}
+	return utils.GetPayload(env)
+}
+
+// firstTxTimestamp returns the timestamp of the first transaction in the block, or nil if the block has no transactions
+func firstTxTimestamp(block *common.Block) (*timestamp.Timestamp, error) {
+	if len(block.Data.Data) == 0 {
+		return nil, nil
+	}
+	payload, err := getTxPayload(block.Data.Data[0])
+	if err != nil {
+		return nil, err
+	}
+	return payload.Header.ChainHeader.Timestamp, nil
+}
+
+// GetBlocksIterator returns an iterator that starts from `startBlockNumber`(inclusive).
+// The iterator is a blocking iterator i.e., it blocks till the next block gets available in the ledger
+// ResultsIterator contains type BlockHolder
+func (l *KVLedger) GetBlocksIterator(startBlockNumber uint64) (ledger.ResultsIterator, error) {
+	return l.blockStore.RetrieveBlocks(startBlockNumber)
+
+}
+
+// BlockListener is invoked once per block by ReplayBlocks, in increasing order of block number.
+// Returning a non-nil error aborts the replay.
+type BlockListener func(block *common.Block) error
+
+// ReplayBlocks delivers the blocks in the range [startBlockNumber, endBlockNumber] (inclusive) to the
+// supplied listener, one at a time and in order. Unlike GetBlocksIterator, this call does not block
+// waiting for blocks that have not yet been committed; endBlockNumber must not exceed the current height.
+func (l *KVLedger) ReplayBlocks(startBlockNumber, endBlockNumber uint64, listener BlockListener) error {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	if startBlockNumber > endBlockNumber {
+		return fmt.Errorf("startBlockNumber [%d] is greater than endBlockNumber [%d]", startBlockNumber, endBlockNumber)
+	}
+	if endBlockNumber > info.Height {
+		return fmt.Errorf("endBlockNumber [%d] exceeds current blockchain height [%d]", endBlockNumber, info.Height)
+	}
+	for blockNumber := startBlockNumber; blockNumber <= endBlockNumber; blockNumber++ {
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return err
+		}
+		if err := listener(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamBlocksForChaincode delivers, in increasing block number order, every block from
+// startBlockNumber (inclusive) up to the current chain height that contains at least one
+// transaction invoking chaincodeID. Delivered blocks have their transaction data stripped down to
+// an empty BlockData, leaving only the header and metadata, since consumers of a chaincode-filtered
+// stream are typically following invocation activity rather than re-executing it. Respects cancel
+// for early exit, in which case it returns nil.
+func (l *KVLedger) StreamBlocksForChaincode(startBlockNumber uint64, chaincodeID string, listener BlockListener, cancel <-chan struct{}) error {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	for blockNumber := startBlockNumber; blockNumber <= info.Height; blockNumber++ {
+		select {
+		case <-cancel:
+			return nil
+		default:
+		}
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return err
+		}
+		invokesChaincode, err := blockInvokesChaincode(block, chaincodeID)
+		if err != nil {
+			return err
+		}
+		if !invokesChaincode {
+			continue
+		}
+		if err := listener(&common.Block{Header: block.Header, Data: &common.BlockData{}, Metadata: block.Metadata}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamBlocks delivers, in increasing block number order, every block from startBlockNumber
+// (inclusive) onward, blocking to wait for blocks not yet committed instead of stopping at the
+// current height the way ReplayBlocks does, so callers can tail the chain head without polling
+// GetBlockCount/GetBlockByNumber in a loop. Delivered blocks have deploy payloads stripped, exactly
+// as GetBlockByNumberStripped does for the unary, single-block path. Closing cancel unblocks the
+// underlying blocking iterator and causes StreamBlocks to return nil, so callers can use it to react
+// to a client disconnecting.
+func (l *KVLedger) StreamBlocks(startBlockNumber uint64, listener BlockListener, cancel <-chan struct{}) error {
+	itr, err := l.GetBlocksIterator(startBlockNumber)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-cancel:
+			itr.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		result, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			return nil
+		}
+		block := result.(ledger.BlockHolder).GetBlock()
+		strippedBlock, err := stripDeployPayloads(block)
+		if err != nil {
+			return err
+		}
+		if err := listener(strippedBlock); err != nil {
+			return err
+		}
+	}
+}
+
+// blockInvokesChaincode reports whether any transaction in block targets chaincodeID. Transactions
+// that are not endorser transactions (e.g., configuration transactions) are skipped rather than
+// treated as a match or an error.
+func blockInvokesChaincode(block *common.Block, chaincodeID string) (bool, error) {
+	for _, envBytes := range block.Data.Data {
+		invokes, err := envelopeInvokesChaincode(envBytes, chaincodeID)
+		if err != nil {
+			return false, err
+		}
+		if invokes {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// lcccName is the name of the lifecycle system chaincode that install/instantiate/upgrade
+// transactions ("deploy" transactions) are submitted against.
+const lcccName = "lccc"
+
+// GetBlockByNumberStripped returns the block at a given height with its deploy transactions'
+// bytes replaced by empty transaction bytes, since a deploy transaction's embedded
+// ChaincodeDeploymentSpec (chaincode bytecode) typically dwarfs the rest of the block and is
+// rarely of interest to callers scanning a range of blocks. When the block has no deploy
+// transactions there is nothing to strip, so the stored block is returned directly without the
+// per-transaction walk or the cost of reconstructing a new block.
+func (l *KVLedger) GetBlockByNumberStripped(blockNumber uint64) (*common.Block, error) {
+	block, err := l.GetBlockByNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return stripDeployPayloads(block)
+}
+
+// GetBlockByNumberWithOptions is the single entry point underlying both GetBlockByNumber and
+// GetBlockByNumberStripped, for callers that decide at runtime whether they want a deploy
+// transaction's CodePackage. includeCodePackage true returns the block exactly as stored, the same
+// as GetBlockByNumber; false strips deploy payloads, the same as GetBlockByNumberStripped.
+func (l *KVLedger) GetBlockByNumberWithOptions(blockNumber uint64, includeCodePackage bool) (*common.Block, error) {
+	if includeCodePackage {
+		return l.GetBlockByNumber(blockNumber)
+	}
+	return l.GetBlockByNumberStripped(blockNumber)
+}
+
+// GetBlockByHashStripped is the hash-addressed counterpart to GetBlockByNumberStripped, applying the
+// same deploy-payload-stripping logic to the block resolved by blockHash. Returns ErrNotFound (via
+// GetBlockByHash) when no block matches the supplied hash.
+func (l *KVLedger) GetBlockByHashStripped(blockHash []byte) (*common.Block, error) {
+	block, err := l.GetBlockByHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return stripDeployPayloads(block)
+}
+
+// stripDeployPayloads returns a copy of block in which the whole envelope of every deploy
+// transaction (one invoking the lccc lifecycle chaincode) is replaced by an empty byte slice, so
+// lightweight listing views aren't forced to transfer chaincode archives. A block with no deploy
+// transaction is returned as is, not copied.
+func stripDeployPayloads(block *common.Block) (*common.Block, error) {
+	hasDeploy, err := blockInvokesChaincode(block, lcccName)
+	if err != nil {
+		return nil, err
+	}
+	if !hasDeploy {
+		return block, nil
+	}
+	strippedData := make([][]byte, len(block.Data.Data))
+	for i, envBytes := range block.Data.Data {
+		isDeploy, err := envelopeInvokesChaincode(envBytes, lcccName)
+		if err != nil {
+			return nil, err
+		}
+		if isDeploy {
+			strippedData[i] = []byte{}
+			continue
+		}
+		strippedData[i] = envBytes
+	}
+	return &common.Block{Header: block.Header, Data: &common.BlockData{Data: strippedData}, Metadata: block.Metadata}, nil
+}
+
+// GetMedianBlock returns the block at height/2 (deploy payloads stripped, as with
+// GetBlockByNumberStripped), giving bisection tooling a representative mid-chain block without
+// having to compute the index client-side. Returns ErrEmptyBlockchain if the chain has no blocks.
+func (l *KVLedger) GetMedianBlock() (*common.Block, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info.Height == 0 {
+		return nil, ErrEmptyBlockchain
+	}
+	medianBlockNumber := info.Height / 2
+	if medianBlockNumber == 0 {
+		medianBlockNumber = 1
+	}
+	return l.GetBlockByNumberStripped(medianBlockNumber)
+}
+
+// envelopeInvokesChaincode reports whether a single marshaled envelope targets chaincodeID.
+func envelopeInvokesChaincode(envBytes []byte, chaincodeID string) (bool, error) {
+	payload, err := getTxPayload(envBytes)
+	if err != nil {
+		return false, err
+	}
+	hdrExt, err := utils.GetChaincodeHeaderExtension(payload.Header)
+	if err != nil {
+		return false, nil
+	}
+	return hdrExt.ChaincodeID != nil && hdrExt.ChaincodeID.Name == chaincodeID, nil
+}
+
+// BackupBlocks streams every block from startBlockNumber (inclusive) through the current tip to w, in
+// order, as length-prefixed serialized blocks, followed by a trailing 4-byte big-endian CRC32 checksum
+// of everything written before it. A startBlockNumber of 0 is treated the same as 1, the first block.
+// Passing one more than the block number a caller last received as startBlockNumber on a retry allows
+// the backup to resume without re-streaming blocks the caller already has. If cancel is closed before the backup
+// completes, BackupBlocks stops streaming and returns an error.
+func (l *KVLedger) BackupBlocks(startBlockNumber uint64, w io.Writer, cancel <-chan struct{}) error {
+	if startBlockNumber == 0 {
+		startBlockNumber = 1
+	}
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	checksum := crc32.NewIEEE()
+	out := io.MultiWriter(w, checksum)
+	lenBuf := make([]byte, 4)
+	for blockNumber := startBlockNumber; blockNumber <= info.Height; blockNumber++ {
+		select {
+		case <-cancel:
+			return errors.New("Backup cancelled")
+		default:
+		}
+		block, err := l.GetBlockByNumber(blockNumber)
+		if err != nil {
+			return err
+		}
+		blockBytes, err := proto.Marshal(block)
+		if err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint32(lenBuf, uint32(len(blockBytes)))
+		if _, err := out.Write(lenBuf); err != nil {
+			return err
+		}
+		if _, err := out.Write(blockBytes); err != nil {
+			return err
+		}
+	}
+	checksumBuf := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksumBuf, checksum.Sum32())
+	_, err = w.Write(checksumBuf)
+	return err
+}
+
+// GetBlockByHash returns a block given it's hash
+func (l *KVLedger) GetBlockByHash(blockHash []byte) (*common.Block, error) {
+	return l.blockStore.RetrieveBlockByHash(blockHash)
+}
+
+//Prune prunes the blocks/transactions that satisfy the given policy
+func (l *KVLedger) Prune(policy ledger.PrunePolicy) error {
+	return errors.New("Not yet implemented")
+}
+
+// GetEarliestRetainedBlock returns the lowest block number still retained locally, so a client of a
+// replica that has pruned early history knows the range of blocks actually available. Since Prune is
+// not yet implemented, every committed block is always retained: this reports the genesis block
+// number (1), or 0 if the chain has no blocks yet.
+func (l *KVLedger) GetEarliestRetainedBlock() (uint64, error) {
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return 0, err
+	}
+	if info.Height == 0 {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// NewTxSimulator returns new `ledger.TxSimulator`
+func (l *KVLedger) NewTxSimulator() (ledger.TxSimulator, error) {
+	return l.txtmgmt.NewTxSimulator()
+}
+
+// NewQueryExecutor gives handle to a query executor.
+// A client can obtain more than one 'QueryExecutor's for parallel execution.
+// Any synchronization should be performed at the implementation level if required
+func (l *KVLedger) NewQueryExecutor() (ledger.QueryExecutor, error) {
+	qe, err := l.txtmgmt.NewQueryExecutor()
+	if err != nil {
+		return nil, err
+	}
+	return ledger.NewRedactingQueryExecutor(qe), nil
 }
 
 // NewHistoryQueryExecutor gives handle to a history query executor.
@@ -17,12 +17,33 @@ limitations under the License.
 package kvledger
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	coreledger "github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/core/ledger/testutil"
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/core/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric/protos/peer"
+	ptestutils "github.com/hyperledger/fabric/protos/testutils"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -77,6 +98,2132 @@ func TestKVLedgerBlockStorage(t *testing.T) {
 	testutil.AssertEquals(t, b2, block2)
 }
 
+func TestKVLedgerCompareBlockHash(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	bg := testutil.NewBlockGenerator(t)
+	block1 := bg.NextBlock([][]byte{simRes}, false)
+	ledger.Commit(block1)
+
+	matches, err := ledger.CompareBlockHash(1, block1.Header.Hash())
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, matches, true)
+
+	matches, err = ledger.CompareBlockHash(1, []byte("not the right hash"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, matches, false)
+
+	_, err = ledger.CompareBlockHash(2, block1.Header.Hash())
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerStreamBlocksForChaincode(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+
+	// block1 carries a transaction for chaincode "foo" (the chaincode name baked into testutil.ConstructTransaction)
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	block1 := bg.NextBlock([][]byte{simRes}, false)
+	ledger.Commit(block1)
+
+	// block2 carries a transaction for a different chaincode, "bar"
+	barEnv, err := ptestutils.ConstructUnsingedTxEnv(util.GenerateUUID(), util.GetTestChainID(), "bar", simRes, nil, nil)
+	testutil.AssertNoError(t, err, "")
+	barEnvBytes, err := proto.Marshal(barEnv)
+	testutil.AssertNoError(t, err, "")
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Data.Data = [][]byte{barEnvBytes}
+	block2.Header.DataHash = block2.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block2, true)
+	ledger.blockStore.AddBlock(block2)
+
+	var streamed []*common.Block
+	err = ledger.StreamBlocksForChaincode(1, "foo", func(block *common.Block) error {
+		streamed = append(streamed, block)
+		return nil
+	}, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(streamed), 1)
+	testutil.AssertEquals(t, streamed[0].Header.Number, uint64(1))
+	testutil.AssertEquals(t, len(streamed[0].Data.Data), 0)
+
+	cancel := make(chan struct{})
+	close(cancel)
+	streamed = nil
+	err = ledger.StreamBlocksForChaincode(1, "foo", func(block *common.Block) error {
+		streamed = append(streamed, block)
+		return nil
+	}, cancel)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(streamed), 0)
+}
+
+// constructTxEnvWithEndorsements builds a transaction envelope for ccName carrying numEndorsements
+// copies of the same endorsement, returning the envelope and its transaction id.
+func constructTxEnvWithEndorsements(t *testing.T, ccName string, simulationResults []byte, numEndorsements int) (*common.Envelope, string) {
+	mspLcl := msp.NewNoopMsp()
+	sigID, err := mspLcl.GetDefaultSigningIdentity()
+	testutil.AssertNoError(t, err, "")
+	creator, err := sigID.Serialize()
+	testutil.AssertNoError(t, err, "")
+
+	txid := util.GenerateUUID()
+	prop, err := putils.CreateChaincodeProposal(txid, util.GetTestChainID(),
+		&pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: ccName}}}, creator)
+	testutil.AssertNoError(t, err, "")
+
+	presp, err := putils.CreateProposalResponse(prop.Header, prop.Payload, simulationResults, nil, nil, sigID)
+	testutil.AssertNoError(t, err, "")
+
+	resps := make([]*pb.ProposalResponse, numEndorsements)
+	for i := range resps {
+		resps[i] = presp
+	}
+	env, err := putils.CreateSignedTx(prop, sigID, resps...)
+	testutil.AssertNoError(t, err, "")
+	return env, txid
+}
+
+// constructConfigTxEnv builds a transaction envelope carrying a valid chaincode action payload, like
+// constructTxEnvWithEndorsements, but stamped as a configuration transaction so that blockIsConfig
+// recognizes the block carrying it. GetActionFromEnvelope only looks at the envelope's
+// Transaction/ChaincodeAction payload, never its ChainHeader.Type, so re-stamping the type after
+// construction does not affect validation.
+func constructConfigTxEnv(t *testing.T, ccName string, simulationResults []byte) *common.Envelope {
+	env, _ := constructTxEnvWithEndorsements(t, ccName, simulationResults, 1)
+	payload, err := putils.GetPayload(env)
+	testutil.AssertNoError(t, err, "")
+	payload.Header.ChainHeader.Type = int32(common.HeaderType_CONFIGURATION_TRANSACTION)
+	payloadBytes, err := proto.Marshal(payload)
+	testutil.AssertNoError(t, err, "")
+	env.Payload = payloadBytes
+	return env
+}
+
+func TestKVLedgerGetEndorsementCoverage(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	exactEnv, exactTxID := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	overEnv, overTxID := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 3)
+
+	exactEnvBytes, err := proto.Marshal(exactEnv)
+	testutil.AssertNoError(t, err, "")
+	overEnvBytes, err := proto.Marshal(overEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{exactEnvBytes, overEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block, true)
+	ledger.blockStore.AddBlock(block)
+
+	coverage, err := ledger.GetEndorsementCoverage(exactTxID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, coverage, &EndorsementCoverage{Present: 1, Required: 1, Satisfied: true})
+
+	coverage, err = ledger.GetEndorsementCoverage(overTxID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, coverage, &EndorsementCoverage{Present: 3, Required: 1, Satisfied: true})
+
+	_, err = ledger.GetEndorsementCoverage("nonexistent-tx")
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerGetTransactionResponse(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	rwSetBytes, err := (&rwset.TxReadWriteSet{}).Marshal()
+	testutil.AssertNoError(t, err, "")
+
+	mspLcl := msp.NewNoopMsp()
+	sigID, err := mspLcl.GetDefaultSigningIdentity()
+	testutil.AssertNoError(t, err, "")
+	creator, err := sigID.Serialize()
+	testutil.AssertNoError(t, err, "")
+
+	txid := util.GenerateUUID()
+	prop, err := putils.CreateChaincodeProposal(txid, util.GetTestChainID(),
+		&pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: "foo"}}}, creator)
+	testutil.AssertNoError(t, err, "")
+	presp, err := putils.CreateProposalResponse(prop.Header, prop.Payload, rwSetBytes, []byte("return-value"), nil, sigID)
+	testutil.AssertNoError(t, err, "")
+	txEnv, err := putils.CreateSignedTx(prop, sigID, presp)
+	testutil.AssertNoError(t, err, "")
+
+	invalidEnv, invalidTxID := constructTxEnvWithEndorsements(t, "foo", []byte("not a valid rwset"), 1)
+
+	txEnvBytes, err := proto.Marshal(txEnv)
+	testutil.AssertNoError(t, err, "")
+	invalidEnvBytes, err := proto.Marshal(invalidEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{txEnvBytes, invalidEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	ledger.blockStore.AddBlock(block)
+
+	resp, err := ledger.GetTransactionResponse(txid)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, resp.Status, int32(200))
+	testutil.AssertEquals(t, resp.Payload, []byte("return-value"))
+
+	resp, err = ledger.GetTransactionResponse(invalidTxID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, resp.Status, int32(500))
+
+	_, err = ledger.GetTransactionResponse("nonexistent-tx")
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerGetTxSetHash(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	env1, txID1 := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	env2, txID2 := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	env1Bytes, err := proto.Marshal(env1)
+	testutil.AssertNoError(t, err, "")
+	env2Bytes, err := proto.Marshal(env2)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{env1Bytes, env2Bytes}
+	block.Header.DataHash = block.Data.Hash()
+	ledger.blockStore.AddBlock(block)
+
+	hash, err := ledger.GetTxSetHash([]string{txID1, txID2})
+	testutil.AssertNoError(t, err, "")
+
+	reorderedHash, err := ledger.GetTxSetHash([]string{txID2, txID1})
+	testutil.AssertNoError(t, err, "")
+	if bytes.Equal(hash, reorderedHash) {
+		t.Fatal("expected reordering the transaction ids to change the hash")
+	}
+
+	sameHash, err := ledger.GetTxSetHash([]string{txID1, txID2})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, hash, sameHash)
+
+	_, err = ledger.GetTxSetHash([]string{txID1, "nonexistent-tx"})
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerGetChaincodeTxDistribution(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	fooEnv1, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	fooEnv2, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	fooEnv3, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	barEnv, _ := constructTxEnvWithEndorsements(t, "bar", []byte("results"), 1)
+
+	fooEnv1Bytes, err := proto.Marshal(fooEnv1)
+	testutil.AssertNoError(t, err, "")
+	fooEnv2Bytes, err := proto.Marshal(fooEnv2)
+	testutil.AssertNoError(t, err, "")
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{fooEnv1Bytes, fooEnv2Bytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block1, true)
+	ledger.blockStore.AddBlock(block1)
+
+	fooEnv3Bytes, err := proto.Marshal(fooEnv3)
+	testutil.AssertNoError(t, err, "")
+	barEnvBytes, err := proto.Marshal(barEnv)
+	testutil.AssertNoError(t, err, "")
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Data.Data = [][]byte{fooEnv3Bytes, barEnvBytes}
+	block2.Header.DataHash = block2.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block2, true)
+	ledger.blockStore.AddBlock(block2)
+
+	distribution, err := ledger.GetChaincodeTxDistribution(1, 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, distribution, map[string]uint64{"foo": 3, "bar": 1})
+
+	_, err = ledger.GetChaincodeTxDistribution(1, maxBlockRangeSize+1)
+	testutil.AssertEquals(t, err, ErrBlockRangeTooLarge)
+}
+
+func TestKVLedgerGetActiveChaincodes(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	_, err := ledger.GetActiveChaincodes(10)
+	testutil.AssertEquals(t, err, ErrEmptyBlockchain)
+
+	oldEnv, _ := constructTxEnvWithEndorsements(t, "old-chaincode", []byte("results"), 1)
+	oldEnvBytes, err := proto.Marshal(oldEnv)
+	testutil.AssertNoError(t, err, "")
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{oldEnvBytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block1, true)
+	ledger.blockStore.AddBlock(block1)
+
+	fooEnv1, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	fooEnv2, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	barEnv, _ := constructTxEnvWithEndorsements(t, "bar", []byte("results"), 1)
+	fooEnv1Bytes, err := proto.Marshal(fooEnv1)
+	testutil.AssertNoError(t, err, "")
+	fooEnv2Bytes, err := proto.Marshal(fooEnv2)
+	testutil.AssertNoError(t, err, "")
+	barEnvBytes, err := proto.Marshal(barEnv)
+	testutil.AssertNoError(t, err, "")
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Data.Data = [][]byte{fooEnv1Bytes, fooEnv2Bytes, barEnvBytes}
+	block2.Header.DataHash = block2.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block2, true)
+	ledger.blockStore.AddBlock(block2)
+
+	activity, err := ledger.GetActiveChaincodes(1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, activity, []*ChaincodeActivity{
+		{ChaincodeID: "foo", InvocationCount: 2},
+		{ChaincodeID: "bar", InvocationCount: 1},
+	})
+}
+
+// constructTxEnvWithCreator builds a minimal endorser transaction envelope whose creator serializes
+// to mspID, for tests of MSP-id attribution.
+func constructTxEnvWithCreator(t *testing.T, mspID string) *common.Envelope {
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID})
+	testutil.AssertNoError(t, err, "")
+	chainHeader := putils.MakeChainHeader(common.HeaderType_ENDORSER_TRANSACTION, 1, util.GetTestChainID(), 0)
+	sigHeader := putils.MakeSignatureHeader(creator, nil)
+	payload := &common.Payload{Header: putils.MakePayloadHeader(chainHeader, sigHeader)}
+	payloadBytes, err := proto.Marshal(payload)
+	testutil.AssertNoError(t, err, "")
+	return &common.Envelope{Payload: payloadBytes}
+}
+
+func TestKVLedgerGetTxCountByOrg(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	org1Env1Bytes, err := proto.Marshal(constructTxEnvWithCreator(t, "Org1MSP"))
+	testutil.AssertNoError(t, err, "")
+	org1Env2Bytes, err := proto.Marshal(constructTxEnvWithCreator(t, "Org1MSP"))
+	testutil.AssertNoError(t, err, "")
+	org2EnvBytes, err := proto.Marshal(constructTxEnvWithCreator(t, "Org2MSP"))
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{org1Env1Bytes, org1Env2Bytes, org2EnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	ledger.blockStore.AddBlock(block)
+
+	counts, err := ledger.GetTxCountByOrg(1, 1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, counts, map[string]uint64{"Org1MSP": 2, "Org2MSP": 1})
+
+	_, err = ledger.GetTxCountByOrg(1, maxBlockRangeSize+1)
+	testutil.AssertEquals(t, err, ErrBlockRangeTooLarge)
+}
+
+func constructRWSetEnv(t *testing.T, ccName string, reads []string, writes map[string][]byte) *common.Envelope {
+	nsRW := &rwset.NsReadWriteSet{NameSpace: ccName}
+	for _, key := range reads {
+		nsRW.Reads = append(nsRW.Reads, rwset.NewKVRead(key, nil))
+	}
+	for key, value := range writes {
+		nsRW.Writes = append(nsRW.Writes, rwset.NewKVWrite(key, value))
+	}
+	txRWSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{nsRW}}
+	resultsBytes, err := txRWSet.Marshal()
+	testutil.AssertNoError(t, err, "")
+	env, _ := constructTxEnvWithEndorsements(t, ccName, resultsBytes, 1)
+	return env
+}
+
+// constructTxEnvWithEndorsers builds a transaction endorsed once per mspID in mspIDs, with each
+// endorsement's identity bytes overridden to carry that org's MSP id, for tests that need to
+// distinguish endorsements by organization.
+func constructTxEnvWithEndorsers(t *testing.T, ccName string, mspIDs ...string) (*common.Envelope, string) {
+	mspLcl := msp.NewNoopMsp()
+	sigID, err := mspLcl.GetDefaultSigningIdentity()
+	testutil.AssertNoError(t, err, "")
+	creator, err := sigID.Serialize()
+	testutil.AssertNoError(t, err, "")
+
+	txid := util.GenerateUUID()
+	prop, err := putils.CreateChaincodeProposal(txid, util.GetTestChainID(),
+		&pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: ccName}}}, creator)
+	testutil.AssertNoError(t, err, "")
+
+	resps := make([]*pb.ProposalResponse, len(mspIDs))
+	for i, mspID := range mspIDs {
+		presp, err := putils.CreateProposalResponse(prop.Header, prop.Payload, []byte("results"), nil, nil, sigID)
+		testutil.AssertNoError(t, err, "")
+		endorserBytes, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID})
+		testutil.AssertNoError(t, err, "")
+		presp.Endorsement.Endorser = endorserBytes
+		resps[i] = presp
+	}
+	env, err := putils.CreateSignedTx(prop, sigID, resps...)
+	testutil.AssertNoError(t, err, "")
+	return env, txid
+}
+
+func TestKVLedgerGetTransactionsEndorsedByOrg(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	org1OnlyEnv, org1OnlyTxID := constructTxEnvWithEndorsers(t, "foo", "Org1MSP")
+	bothOrgsEnv, bothOrgsTxID := constructTxEnvWithEndorsers(t, "foo", "Org1MSP", "Org2MSP")
+	org2OnlyEnv, _ := constructTxEnvWithEndorsers(t, "foo", "Org2MSP")
+
+	org1OnlyEnvBytes, err := proto.Marshal(org1OnlyEnv)
+	testutil.AssertNoError(t, err, "")
+	bothOrgsEnvBytes, err := proto.Marshal(bothOrgsEnv)
+	testutil.AssertNoError(t, err, "")
+	org2OnlyEnvBytes, err := proto.Marshal(org2OnlyEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{org1OnlyEnvBytes, bothOrgsEnvBytes, org2OnlyEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	ledger.blockStore.AddBlock(block)
+
+	org1Txs, err := ledger.GetTransactionsEndorsedByOrg(1, 1, "Org1MSP")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, org1Txs, []string{org1OnlyTxID, bothOrgsTxID})
+
+	_, err = ledger.GetTransactionsEndorsedByOrg(1, maxBlockRangeSize+1, "Org1MSP")
+	testutil.AssertEquals(t, err, ErrBlockRangeTooLarge)
+}
+
+func TestKVLedgerGetReadOnlyChaincodes(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	readOnlyEnv := constructRWSetEnv(t, "readonlycc", []string{"k1"}, nil)
+	readWriteEnv := constructRWSetEnv(t, "readwritecc", []string{"k1"}, map[string][]byte{"k1": []byte("v1")})
+	readOnlyEnvBytes, err := proto.Marshal(readOnlyEnv)
+	testutil.AssertNoError(t, err, "")
+	readWriteEnvBytes, err := proto.Marshal(readWriteEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{readOnlyEnvBytes, readWriteEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	ledger.blockStore.AddBlock(block)
+
+	readOnlyChaincodes, err := ledger.GetReadOnlyChaincodes(1, 1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, readOnlyChaincodes, []string{"readonlycc"})
+
+	_, err = ledger.GetReadOnlyChaincodes(1, maxBlockRangeSize+1)
+	testutil.AssertEquals(t, err, ErrBlockRangeTooLarge)
+}
+
+func TestKVLedgerCountStateDeletes(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	deleteEnv := constructRWSetEnv(t, "foo", nil, map[string][]byte{"k1": nil, "k2": nil})
+	writeOnlyEnv := constructRWSetEnv(t, "foo", nil, map[string][]byte{"k3": []byte("v3")})
+	deleteEnvBytes, err := proto.Marshal(deleteEnv)
+	testutil.AssertNoError(t, err, "")
+	writeOnlyEnvBytes, err := proto.Marshal(writeOnlyEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{deleteEnvBytes, writeOnlyEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	ledger.blockStore.AddBlock(block)
+
+	count, err := ledger.CountStateDeletes(1, 1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, count, uint64(2))
+
+	_, err = ledger.CountStateDeletes(1, maxBlockRangeSize+1)
+	testutil.AssertEquals(t, err, ErrBlockRangeTooLarge)
+}
+
+func TestKVLedgerGetTxTypeCounts(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	configChainHeader := putils.MakeChainHeader(common.HeaderType_CONFIGURATION_TRANSACTION, 1, util.GetTestChainID(), 0)
+	configPayload := &common.Payload{Header: putils.MakePayloadHeader(configChainHeader, nil)}
+	configPayloadBytes, err := proto.Marshal(configPayload)
+	testutil.AssertNoError(t, err, "")
+	configEnvBytes, err := proto.Marshal(&common.Envelope{Payload: configPayloadBytes})
+	testutil.AssertNoError(t, err, "")
+
+	deployEnv, _ := constructTxEnvWithEndorsements(t, lcccName, []byte("results"), 1)
+	deployEnvBytes, err := proto.Marshal(deployEnv)
+	testutil.AssertNoError(t, err, "")
+
+	invokeEnv, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	invokeEnvBytes, err := proto.Marshal(invokeEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{configEnvBytes, deployEnvBytes, invokeEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	ledger.blockStore.AddBlock(block)
+
+	counts, err := ledger.GetTxTypeCounts(1, 1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, counts, map[string]uint64{"config": 1, "deploy": 1, "invoke": 1})
+
+	_, err = ledger.GetTxTypeCounts(1, maxBlockRangeSize+1)
+	testutil.AssertEquals(t, err, ErrBlockRangeTooLarge)
+}
+
+func TestKVLedgerGetStateWithProvenance(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	_, err := ledger.GetStateWithProvenance("foo", "key1")
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+
+	nsRW := &rwset.NsReadWriteSet{NameSpace: "foo", Writes: []*rwset.KVWrite{rwset.NewKVWrite("key1", []byte("value1"))}}
+	txRWSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{nsRW}}
+	resultsBytes, err := txRWSet.Marshal()
+	testutil.AssertNoError(t, err, "")
+	txEnv, txID := constructTxEnvWithEndorsements(t, "foo", resultsBytes, 1)
+	txEnvBytes, err := proto.Marshal(txEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{txEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	testutil.AssertNoError(t, ledger.Commit(block), "")
+
+	provenance, err := ledger.GetStateWithProvenance("foo", "key1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, provenance, &StateProvenance{Value: []byte("value1"), BlockNumber: 1, TxID: txID})
+}
+
+func TestKVLedgerExportStateJSON(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	nsRW := &rwset.NsReadWriteSet{NameSpace: "foo", Writes: []*rwset.KVWrite{
+		rwset.NewKVWrite("key1", []byte("value1")),
+		rwset.NewKVWrite("key2", []byte{0x00, 0x01, 0x02}),
+	}}
+	txRWSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{nsRW}}
+	resultsBytes, err := txRWSet.Marshal()
+	testutil.AssertNoError(t, err, "")
+	txEnv, _ := constructTxEnvWithEndorsements(t, "foo", resultsBytes, 1)
+	txEnvBytes, err := proto.Marshal(txEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{txEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	testutil.AssertNoError(t, ledger.Commit(block), "")
+
+	var buf bytes.Buffer
+	testutil.AssertNoError(t, ledger.ExportStateJSON("foo", &buf), "")
+
+	var exported []exportedKV
+	testutil.AssertNoError(t, json.Unmarshal(buf.Bytes(), &exported), "")
+	testutil.AssertEquals(t, len(exported), 2)
+
+	values := map[string][]byte{}
+	for _, kv := range exported {
+		values[kv.Key] = kv.Value
+	}
+	testutil.AssertEquals(t, values["key1"], []byte("value1"))
+	testutil.AssertEquals(t, values["key2"], []byte{0x00, 0x01, 0x02})
+}
+
+func TestKVLedgerGetStateByPattern(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	nsRW := &rwset.NsReadWriteSet{NameSpace: "foo", Writes: []*rwset.KVWrite{
+		rwset.NewKVWrite("acct.alice", []byte("100")),
+		rwset.NewKVWrite("acct.bob", []byte("200")),
+		rwset.NewKVWrite("log.acct.alice", []byte("opened")),
+		rwset.NewKVWrite("other", []byte("300")),
+	}}
+	txRWSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{nsRW}}
+	resultsBytes, err := txRWSet.Marshal()
+	testutil.AssertNoError(t, err, "")
+	txEnv, _ := constructTxEnvWithEndorsements(t, "foo", resultsBytes, 1)
+	txEnvBytes, err := proto.Marshal(txEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{txEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	testutil.AssertNoError(t, ledger.Commit(block), "")
+
+	// prefix pattern
+	page, bookmark, err := ledger.GetStateByPattern("foo", "acct.*", 10, "")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, bookmark, "")
+	keys := map[string]bool{}
+	for _, kv := range page {
+		keys[kv.Key] = true
+	}
+	testutil.AssertEquals(t, keys, map[string]bool{"acct.alice": true, "acct.bob": true})
+
+	// suffix pattern
+	page, bookmark, err = ledger.GetStateByPattern("foo", "*.alice", 10, "")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, bookmark, "")
+	keys = map[string]bool{}
+	for _, kv := range page {
+		keys[kv.Key] = true
+	}
+	testutil.AssertEquals(t, keys, map[string]bool{"acct.alice": true, "log.acct.alice": true})
+
+	// exact pattern
+	page, bookmark, err = ledger.GetStateByPattern("foo", "other", 10, "")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, bookmark, "")
+	testutil.AssertEquals(t, len(page), 1)
+	testutil.AssertEquals(t, page[0].Key, "other")
+	testutil.AssertEquals(t, page[0].Value, []byte("300"))
+
+	// paging over the prefix pattern
+	page, bookmark, err = ledger.GetStateByPattern("foo", "acct.*", 1, "")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(page), 1)
+	testutil.AssertNotEquals(t, bookmark, "")
+
+	page, bookmark, err = ledger.GetStateByPattern("foo", "acct.*", 1, bookmark)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(page), 1)
+	testutil.AssertEquals(t, bookmark, "")
+
+	_, _, err = ledger.GetStateByPattern("foo", "a*b*c", 10, "")
+	if err == nil {
+		t.Fatal("expected an error for a pattern with more than one wildcard")
+	}
+}
+
+func TestKVLedgerGetStateByRange(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	nsRW := &rwset.NsReadWriteSet{NameSpace: "foo", Writes: []*rwset.KVWrite{
+		rwset.NewKVWrite("acct.alice", []byte("100")),
+		rwset.NewKVWrite("acct.bob", []byte("200")),
+		rwset.NewKVWrite("acct.carol", []byte("300")),
+		rwset.NewKVWrite("other", []byte("400")),
+	}}
+	txRWSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{nsRW}}
+	resultsBytes, err := txRWSet.Marshal()
+	testutil.AssertNoError(t, err, "")
+	txEnv, _ := constructTxEnvWithEndorsements(t, "foo", resultsBytes, 1)
+	txEnvBytes, err := proto.Marshal(txEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{txEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	testutil.AssertNoError(t, ledger.Commit(block), "")
+
+	kvs, truncated, err := ledger.GetStateByRange("foo", "acct.a", "acct.c")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, truncated, false)
+	testutil.AssertEquals(t, len(kvs), 2)
+	testutil.AssertEquals(t, kvs[0].Key, "acct.alice")
+	testutil.AssertEquals(t, kvs[1].Key, "acct.bob")
+
+	kvs, truncated, err = ledger.GetStateByRange("foo", "", "")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, truncated, false)
+	testutil.AssertEquals(t, len(kvs), 4)
+
+	viper.Set("ledger.state.rangeQueryLimit", 2)
+	defer viper.Set("ledger.state.rangeQueryLimit", 0)
+	kvs, truncated, err = ledger.GetStateByRange("foo", "", "")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, truncated, true)
+	testutil.AssertEquals(t, len(kvs), 2)
+}
+
+func TestKVLedgerGetLastConfigBlockNumber(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	_, err := ledger.GetLastConfigBlockNumber()
+	testutil.AssertEquals(t, err, ErrEmptyBlockchain)
+
+	// block1 is the chain's configuration block
+	configEnv := constructConfigTxEnv(t, "foo", []byte("results"))
+	configEnvBytes, err := proto.Marshal(configEnv)
+	testutil.AssertNoError(t, err, "")
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{configEnvBytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block1, true)
+	ledger.blockStore.AddBlock(block1)
+
+	blockNumber, err := ledger.GetLastConfigBlockNumber()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, blockNumber, uint64(1))
+
+	// block2 is an ordinary transaction and should not change the answer
+	fooEnv, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	fooEnvBytes, err := proto.Marshal(fooEnv)
+	testutil.AssertNoError(t, err, "")
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Data.Data = [][]byte{fooEnvBytes}
+	block2.Header.DataHash = block2.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block2, true)
+	ledger.blockStore.AddBlock(block2)
+
+	blockNumber, err = ledger.GetLastConfigBlockNumber()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, blockNumber, uint64(1))
+}
+
+func TestKVLedgerVerifyTransactionProofs(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	txEnv, txID := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	txEnvBytes, err := proto.Marshal(txEnv)
+	testutil.AssertNoError(t, err, "")
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{txEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block, true)
+	ledger.blockStore.AddBlock(block)
+
+	results, err := ledger.VerifyTransactionProofs([]*TransactionProofClaim{
+		{TxID: txID, BlockNumber: 1, ClaimedBlockHash: block.Header.Hash()},
+		{TxID: txID, BlockNumber: 1, ClaimedBlockHash: []byte("wrong-hash")},
+		{TxID: "nonexistent-tx", BlockNumber: 1, ClaimedBlockHash: block.Header.Hash()},
+		{TxID: txID, BlockNumber: 42, ClaimedBlockHash: block.Header.Hash()},
+	})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(results), 4)
+	testutil.AssertEquals(t, results[0], &TransactionProofResult{TxID: txID, Valid: true})
+	testutil.AssertEquals(t, results[1], &TransactionProofResult{TxID: txID, Valid: false})
+	testutil.AssertEquals(t, results[2], &TransactionProofResult{TxID: "nonexistent-tx", Valid: false})
+	testutil.AssertEquals(t, results[3], &TransactionProofResult{TxID: txID, Valid: false})
+}
+
+// fakePeerTipSource is a PeerTipSource backed by a fixed height and block hashes, used to test
+// PeersAgreeOnTip without a real peer-to-peer connection.
+type fakePeerTipSource struct {
+	id     string
+	height uint64
+	hashes map[uint64][]byte
+}
+
+func (f *fakePeerTipSource) PeerID() string { return f.id }
+func (f *fakePeerTipSource) Height() (uint64, error) {
+	return f.height, nil
+}
+func (f *fakePeerTipSource) BlockHash(blockNumber uint64) ([]byte, error) {
+	return f.hashes[blockNumber], nil
+}
+
+func TestKVLedgerPeersAgreeOnTip(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	block1 := bg.NextBlock([][]byte{testutil.ConstructRandomBytes(t, 10)}, false)
+	ledger.Commit(block1)
+	block2 := bg.NextBlock([][]byte{testutil.ConstructRandomBytes(t, 10)}, false)
+	ledger.Commit(block2)
+
+	agreeingPeer := &fakePeerTipSource{id: "peer1", height: 3, hashes: map[uint64][]byte{2: block2.Header.Hash()}}
+	result, err := ledger.PeersAgreeOnTip([]PeerTipSource{agreeingPeer})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result, &AgreementResult{Agree: true, CheckedAt: 2})
+
+	dissentingPeer := &fakePeerTipSource{id: "peer2", height: 3, hashes: map[uint64][]byte{2: []byte("wrong-hash")}}
+	result, err = ledger.PeersAgreeOnTip([]PeerTipSource{agreeingPeer, dissentingPeer})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result, &AgreementResult{Agree: false, CheckedAt: 2, Dissenters: []string{"peer2"}})
+}
+
+func TestKVLedgerGetLaggingPeers(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	block1 := bg.NextBlock([][]byte{testutil.ConstructRandomBytes(t, 10)}, false)
+	ledger.Commit(block1)
+	block2 := bg.NextBlock([][]byte{testutil.ConstructRandomBytes(t, 10)}, false)
+	ledger.Commit(block2)
+	// this ledger is now at height 2
+
+	current := &fakePeerTipSource{id: "current", height: 2}
+	aBitBehind := &fakePeerTipSource{id: "a-bit-behind", height: 1}
+	farBehind := &fakePeerTipSource{id: "far-behind", height: 0}
+
+	lagging, err := ledger.GetLaggingPeers([]PeerTipSource{current, aBitBehind, farBehind}, 1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, lagging, []*LaggingPeer{{PeerID: "far-behind", Height: 0}})
+
+	lagging, err = ledger.GetLaggingPeers([]PeerTipSource{current, aBitBehind, farBehind}, 0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, lagging, []*LaggingPeer{{PeerID: "a-bit-behind", Height: 1}, {PeerID: "far-behind", Height: 0}})
+}
+
+// fakeLatencyPeerTipSource is a PeerTipSource backed by a fixed height and simulated response
+// latency, used to test GetBestValidator without real network calls.
+type fakeLatencyPeerTipSource struct {
+	id      string
+	height  uint64
+	latency time.Duration
+}
+
+func (f *fakeLatencyPeerTipSource) PeerID() string { return f.id }
+func (f *fakeLatencyPeerTipSource) Height() (uint64, error) {
+	time.Sleep(f.latency)
+	return f.height, nil
+}
+func (f *fakeLatencyPeerTipSource) BlockHash(blockNumber uint64) ([]byte, error) { return nil, nil }
+
+func TestKVLedgerGetBestValidator(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	slow := &fakeLatencyPeerTipSource{id: "slow", height: 10, latency: 30 * time.Millisecond}
+	fast := &fakeLatencyPeerTipSource{id: "fast", height: 10, latency: 0}
+	stale := &fakeLatencyPeerTipSource{id: "stale", height: 1, latency: 0}
+
+	best, err := ledger.GetBestValidator([]PeerTipSource{slow, fast, stale}, 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, best, &PeerEndpoint{PeerID: "fast", Height: 10})
+
+	_, err = ledger.GetBestValidator(nil, 0)
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerGetChannelConfig(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	_, err := ledger.GetChannelConfig()
+	testutil.AssertEquals(t, err, ErrEmptyBlockchain)
+
+	configEnvelope := &common.ConfigurationEnvelope{
+		Items: []*common.SignedConfigurationItem{{ConfigurationItem: []byte("batchsize=10")}},
+	}
+	configEnvelopeBytes, err := proto.Marshal(configEnvelope)
+	testutil.AssertNoError(t, err, "")
+	configChainHeader := putils.MakeChainHeader(common.HeaderType_CONFIGURATION_TRANSACTION, 1, util.GetTestChainID(), 0)
+	configPayload := &common.Payload{Header: putils.MakePayloadHeader(configChainHeader, nil), Data: configEnvelopeBytes}
+	configPayloadBytes, err := proto.Marshal(configPayload)
+	testutil.AssertNoError(t, err, "")
+	configEnvBytes, err := proto.Marshal(&common.Envelope{Payload: configPayloadBytes})
+	testutil.AssertNoError(t, err, "")
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{configEnvBytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block1, true)
+	ledger.blockStore.AddBlock(block1)
+
+	fetched, err := ledger.GetChannelConfig()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(fetched.Items), 1)
+	testutil.AssertEquals(t, string(fetched.Items[0].ConfigurationItem), "batchsize=10")
+}
+
+func TestKVLedgerGetTransactionsByValidationCode(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	validEnv, validTxID := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	invalidEnv, invalidTxID := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	validEnvBytes, err := proto.Marshal(validEnv)
+	testutil.AssertNoError(t, err, "")
+	invalidEnvBytes, err := proto.Marshal(invalidEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{validEnvBytes, invalidEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	txsFilter := ledgerutil.NewFilterBitArray(uint(len(block.Data.Data)))
+	txsFilter.Set(1)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txsFilter.ToBytes()
+	ledger.blockStore.AddBlock(block)
+
+	valid, err := ledger.GetTransactionsByValidationCode(1, 1, TxValid)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, valid, []*ValidationResult{{TxID: validTxID, BlockNumber: 1}})
+
+	invalid, err := ledger.GetTransactionsByValidationCode(1, 1, TxValidationCodeMVCCReadConflict)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, invalid, []*ValidationResult{{TxID: invalidTxID, BlockNumber: 1}})
+
+	_, err = ledger.GetTransactionsByValidationCode(1, maxBlockRangeSize+1, TxValid)
+	testutil.AssertEquals(t, err, ErrBlockRangeTooLarge)
+}
+
+func TestKVLedgerGetCompressedBlock(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	block1 := bg.NextBlock([][]byte{testutil.ConstructRandomBytes(t, 100)}, false)
+	ledger.Commit(block1)
+
+	compressed, err := ledger.GetCompressedBlock(1)
+	testutil.AssertNoError(t, err, "")
+
+	gzReader, err := gzip.NewReader(bytes.NewReader(compressed.Data))
+	testutil.AssertNoError(t, err, "")
+	decompressed, err := ioutil.ReadAll(gzReader)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(decompressed), compressed.OriginalSize)
+
+	roundtripped := &common.Block{}
+	testutil.AssertNoError(t, proto.Unmarshal(decompressed, roundtripped), "")
+	testutil.AssertEquals(t, roundtripped.Header.Number, block1.Header.Number)
+
+	_, err = ledger.GetCompressedBlock(42)
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+// constructTxEnvWithID builds a transaction envelope for ccName carrying the given fixed txID, so
+// tests can inject two otherwise-independent transactions that share the same ID.
+func constructTxEnvWithID(t *testing.T, txid string, ccName string, simulationResults []byte) *common.Envelope {
+	mspLcl := msp.NewNoopMsp()
+	sigID, err := mspLcl.GetDefaultSigningIdentity()
+	testutil.AssertNoError(t, err, "")
+	creator, err := sigID.Serialize()
+	testutil.AssertNoError(t, err, "")
+
+	prop, err := putils.CreateChaincodeProposal(txid, util.GetTestChainID(),
+		&pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: ccName}}}, creator)
+	testutil.AssertNoError(t, err, "")
+
+	presp, err := putils.CreateProposalResponse(prop.Header, prop.Payload, simulationResults, nil, nil, sigID)
+	testutil.AssertNoError(t, err, "")
+
+	env, err := putils.CreateSignedTx(prop, sigID, presp)
+	testutil.AssertNoError(t, err, "")
+	return env
+}
+
+func TestKVLedgerFindDuplicateTransactions(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	dupEnv1 := constructTxEnvWithID(t, "dup-tx", "foo", []byte("results1"))
+	dupEnv1Bytes, err := proto.Marshal(dupEnv1)
+	testutil.AssertNoError(t, err, "")
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{dupEnv1Bytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block1, true)
+	ledger.blockStore.AddBlock(block1)
+
+	dupEnv2 := constructTxEnvWithID(t, "dup-tx", "foo", []byte("results2"))
+	dupEnv2Bytes, err := proto.Marshal(dupEnv2)
+	testutil.AssertNoError(t, err, "")
+	uniqueEnv, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results3"), 1)
+	uniqueEnvBytes, err := proto.Marshal(uniqueEnv)
+	testutil.AssertNoError(t, err, "")
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Data.Data = [][]byte{dupEnv2Bytes, uniqueEnvBytes}
+	block2.Header.DataHash = block2.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block2, true)
+	ledger.blockStore.AddBlock(block2)
+
+	duplicates, err := ledger.FindDuplicateTransactions(1, 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, duplicates, []*DuplicateTransaction{{TxID: "dup-tx", BlockNumbers: []uint64{1, 2}}})
+
+	_, err = ledger.FindDuplicateTransactions(1, maxBlockRangeSize+1)
+	testutil.AssertEquals(t, err, ErrBlockRangeTooLarge)
+}
+
+func TestKVLedgerGetAnchorPeers(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	anchorPeersJSON, err := json.Marshal([]*AnchorPeer{{Host: "peer0.org1.example.com", Port: 7051}})
+	testutil.AssertNoError(t, err, "")
+	anchorPeersItem := &common.ConfigurationItem{Key: "AnchorPeers", Value: anchorPeersJSON}
+	anchorPeersItemBytes, err := proto.Marshal(anchorPeersItem)
+	testutil.AssertNoError(t, err, "")
+	configEnvelope := &common.ConfigurationEnvelope{
+		Items: []*common.SignedConfigurationItem{{ConfigurationItem: anchorPeersItemBytes}},
+	}
+	configEnvelopeBytes, err := proto.Marshal(configEnvelope)
+	testutil.AssertNoError(t, err, "")
+	configChainHeader := putils.MakeChainHeader(common.HeaderType_CONFIGURATION_TRANSACTION, 1, util.GetTestChainID(), 0)
+	configPayload := &common.Payload{Header: putils.MakePayloadHeader(configChainHeader, nil), Data: configEnvelopeBytes}
+	configPayloadBytes, err := proto.Marshal(configPayload)
+	testutil.AssertNoError(t, err, "")
+	configEnvBytes, err := proto.Marshal(&common.Envelope{Payload: configPayloadBytes})
+	testutil.AssertNoError(t, err, "")
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{configEnvBytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block1, true)
+	ledger.blockStore.AddBlock(block1)
+
+	anchorPeers, err := ledger.GetAnchorPeers()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, anchorPeers, []*AnchorPeer{{Host: "peer0.org1.example.com", Port: 7051}})
+}
+
+func TestKVLedgerGetAnchorPeersNoneConfigured(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	configEnvelope := &common.ConfigurationEnvelope{
+		Items: []*common.SignedConfigurationItem{{ConfigurationItem: []byte("batchsize=10")}},
+	}
+	configEnvelopeBytes, err := proto.Marshal(configEnvelope)
+	testutil.AssertNoError(t, err, "")
+	configChainHeader := putils.MakeChainHeader(common.HeaderType_CONFIGURATION_TRANSACTION, 1, util.GetTestChainID(), 0)
+	configPayload := &common.Payload{Header: putils.MakePayloadHeader(configChainHeader, nil), Data: configEnvelopeBytes}
+	configPayloadBytes, err := proto.Marshal(configPayload)
+	testutil.AssertNoError(t, err, "")
+	configEnvBytes, err := proto.Marshal(&common.Envelope{Payload: configPayloadBytes})
+	testutil.AssertNoError(t, err, "")
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{configEnvBytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.txtmgmt.ValidateAndPrepare(block1, true)
+	ledger.blockStore.AddBlock(block1)
+
+	anchorPeers, err := ledger.GetAnchorPeers()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, anchorPeers, []*AnchorPeer{})
+}
+
+func TestKVLedgerGetBlockByNumberStripped(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	invokeEnv, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	deployEnv, _ := constructTxEnvWithEndorsements(t, lcccName, []byte("results"), 1)
+	invokeEnvBytes, err := proto.Marshal(invokeEnv)
+	testutil.AssertNoError(t, err, "")
+	deployEnvBytes, err := proto.Marshal(deployEnv)
+	testutil.AssertNoError(t, err, "")
+
+	noDeployBlock := common.NewBlock(1, []byte{})
+	noDeployBlock.Data.Data = [][]byte{invokeEnvBytes}
+	noDeployBlock.Header.DataHash = noDeployBlock.Data.Hash()
+	ledger.blockStore.AddBlock(noDeployBlock)
+
+	fetched, err := ledger.GetBlockByNumberStripped(1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, fetched, noDeployBlock)
+
+	deployBlock := common.NewBlock(2, noDeployBlock.Header.Hash())
+	deployBlock.Data.Data = [][]byte{invokeEnvBytes, deployEnvBytes}
+	deployBlock.Header.DataHash = deployBlock.Data.Hash()
+	ledger.blockStore.AddBlock(deployBlock)
+
+	fetched, err = ledger.GetBlockByNumberStripped(2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, fetched.Data.Data[0], invokeEnvBytes)
+	testutil.AssertEquals(t, len(fetched.Data.Data[1]), 0)
+
+	fullBlock, err := ledger.GetBlockByNumber(2)
+	testutil.AssertNoError(t, err, "")
+	for _, payload := range fullBlock.Data.Data {
+		txPayload, err := getTxPayload(payload)
+		testutil.AssertNoError(t, err, "")
+		hdrExt, err := putils.GetChaincodeHeaderExtension(txPayload.Header)
+		testutil.AssertNoError(t, err, "")
+		if hdrExt.ChaincodeID.Name == lcccName {
+			testutil.AssertNotEquals(t, len(payload), 0)
+		}
+	}
+}
+
+func TestKVLedgerGetBlockByNumberWithOptions(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	invokeEnv, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	deployEnv, _ := constructTxEnvWithEndorsements(t, lcccName, []byte("results"), 1)
+	invokeEnvBytes, err := proto.Marshal(invokeEnv)
+	testutil.AssertNoError(t, err, "")
+	deployEnvBytes, err := proto.Marshal(deployEnv)
+	testutil.AssertNoError(t, err, "")
+
+	deployBlock := common.NewBlock(1, []byte{})
+	deployBlock.Data.Data = [][]byte{invokeEnvBytes, deployEnvBytes}
+	deployBlock.Header.DataHash = deployBlock.Data.Hash()
+	ledger.blockStore.AddBlock(deployBlock)
+
+	withPackage, err := ledger.GetBlockByNumberWithOptions(1, true)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, withPackage, deployBlock)
+
+	stripped, err := ledger.GetBlockByNumberWithOptions(1, false)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, stripped.Data.Data[0], invokeEnvBytes)
+	testutil.AssertEquals(t, len(stripped.Data.Data[1]), 0)
+}
+
+func TestKVLedgerValidateReadSet(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	// block 1: write foo.key1
+	writeNsRW := &rwset.NsReadWriteSet{NameSpace: "foo", Writes: []*rwset.KVWrite{rwset.NewKVWrite("key1", []byte("value1"))}}
+	writeRWSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{writeNsRW}}
+	writeResultsBytes, err := writeRWSet.Marshal()
+	testutil.AssertNoError(t, err, "")
+	writeEnv, _ := constructTxEnvWithEndorsements(t, "foo", writeResultsBytes, 1)
+	writeEnvBytes, err := proto.Marshal(writeEnv)
+	testutil.AssertNoError(t, err, "")
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{writeEnvBytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	testutil.AssertNoError(t, ledger.Commit(block1), "")
+
+	qe, err := ledger.NewQueryExecutor()
+	testutil.AssertNoError(t, err, "")
+	v1, err := qe.GetStateWithVersion("foo", "key1")
+	testutil.AssertNoError(t, err, "")
+	qe.Done()
+
+	// block 2: read foo.key1 at the version just committed, write foo.key2
+	readNsRW := &rwset.NsReadWriteSet{
+		NameSpace: "foo",
+		Reads:     []*rwset.KVRead{rwset.NewKVRead("key1", version.NewHeight(v1.Height.BlockNum, v1.Height.TxNum))},
+		Writes:    []*rwset.KVWrite{rwset.NewKVWrite("key2", []byte("value2"))},
+	}
+	readRWSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{readNsRW}}
+	readResultsBytes, err := readRWSet.Marshal()
+	testutil.AssertNoError(t, err, "")
+	readEnv, readTxID := constructTxEnvWithEndorsements(t, "foo", readResultsBytes, 1)
+	readEnvBytes, err := proto.Marshal(readEnv)
+	testutil.AssertNoError(t, err, "")
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Data.Data = [][]byte{readEnvBytes}
+	block2.Header.DataHash = block2.Data.Hash()
+	testutil.AssertNoError(t, ledger.Commit(block2), "")
+
+	validity, err := ledger.ValidateReadSet(readTxID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, validity.Valid, true)
+
+	// block 3: overwrite foo.key1, invalidating readTxID's recorded read-set
+	overwriteNsRW := &rwset.NsReadWriteSet{NameSpace: "foo", Writes: []*rwset.KVWrite{rwset.NewKVWrite("key1", []byte("value1-updated"))}}
+	overwriteRWSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{overwriteNsRW}}
+	overwriteResultsBytes, err := overwriteRWSet.Marshal()
+	testutil.AssertNoError(t, err, "")
+	overwriteEnv, _ := constructTxEnvWithEndorsements(t, "foo", overwriteResultsBytes, 1)
+	overwriteEnvBytes, err := proto.Marshal(overwriteEnv)
+	testutil.AssertNoError(t, err, "")
+	block3 := common.NewBlock(3, block2.Header.Hash())
+	block3.Data.Data = [][]byte{overwriteEnvBytes}
+	block3.Header.DataHash = block3.Data.Hash()
+	testutil.AssertNoError(t, ledger.Commit(block3), "")
+
+	validity, err = ledger.ValidateReadSet(readTxID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, validity.Valid, false)
+	testutil.AssertEquals(t, validity.ChangedKeys, []string{"foo.key1"})
+
+	_, err = ledger.ValidateReadSet("nonexistent-tx")
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerGetBlockByHashStripped(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	invokeEnv, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	deployEnv, _ := constructTxEnvWithEndorsements(t, lcccName, []byte("results"), 1)
+	invokeEnvBytes, err := proto.Marshal(invokeEnv)
+	testutil.AssertNoError(t, err, "")
+	deployEnvBytes, err := proto.Marshal(deployEnv)
+	testutil.AssertNoError(t, err, "")
+
+	deployBlock := common.NewBlock(1, []byte{})
+	deployBlock.Data.Data = [][]byte{invokeEnvBytes, deployEnvBytes}
+	deployBlock.Header.DataHash = deployBlock.Data.Hash()
+	ledger.blockStore.AddBlock(deployBlock)
+
+	fetched, err := ledger.GetBlockByHashStripped(deployBlock.Header.Hash())
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, fetched.Data.Data[0], invokeEnvBytes)
+	testutil.AssertEquals(t, len(fetched.Data.Data[1]), 0)
+
+	_, err = ledger.GetBlockByHashStripped([]byte("no-such-hash"))
+	if err == nil {
+		t.Fatal("expected an error for an unknown block hash")
+	}
+}
+
+func TestKVLedgerGetMedianBlock(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	_, err := ledger.GetMedianBlock()
+	testutil.AssertEquals(t, err, ErrEmptyBlockchain)
+
+	bg := testutil.NewBlockGenerator(t)
+	var lastBlock *common.Block
+	for i := 0; i < 4; i++ {
+		lastBlock = bg.NextBlock(nil, false)
+		ledger.blockStore.AddBlock(lastBlock)
+	}
+	// even height: 4 blocks committed, height 4, median index 4/2 = 2
+	median, err := ledger.GetMedianBlock()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, median.Header.Number, uint64(2))
+
+	lastBlock = bg.NextBlock(nil, false)
+	ledger.blockStore.AddBlock(lastBlock)
+	// odd height: 5 blocks committed, height 5, median index 5/2 = 2
+	median, err = ledger.GetMedianBlock()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, median.Header.Number, uint64(2))
+}
+
+func TestKVLedgerGetEarliestRetainedBlock(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	earliest, err := ledger.GetEarliestRetainedBlock()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, earliest, uint64(0))
+
+	bg := testutil.NewBlockGenerator(t)
+	ledger.blockStore.AddBlock(bg.NextBlock(nil, false))
+	ledger.blockStore.AddBlock(bg.NextBlock(nil, false))
+
+	earliest, err = ledger.GetEarliestRetainedBlock()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, earliest, uint64(1))
+}
+
+func BenchmarkGetBlockByNumberStripped(b *testing.B) {
+	env := newTestEnv(b)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	mspLcl := msp.NewNoopMsp()
+	sigID, err := mspLcl.GetDefaultSigningIdentity()
+	if err != nil {
+		b.Fatal(err)
+	}
+	creator, err := sigID.Serialize()
+	if err != nil {
+		b.Fatal(err)
+	}
+	prop, err := putils.CreateChaincodeProposal(util.GenerateUUID(), util.GetTestChainID(),
+		&pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: "foo"}}}, creator)
+	if err != nil {
+		b.Fatal(err)
+	}
+	presp, err := putils.CreateProposalResponse(prop.Header, prop.Payload, []byte("results"), nil, nil, sigID)
+	if err != nil {
+		b.Fatal(err)
+	}
+	invokeEnv, err := putils.CreateSignedTx(prop, sigID, presp)
+	if err != nil {
+		b.Fatal(err)
+	}
+	invokeEnvBytes, err := proto.Marshal(invokeEnv)
+	if err != nil {
+		b.Fatal(err)
+	}
+	block := common.NewBlock(1, []byte{})
+	data := make([][]byte, 100)
+	for i := range data {
+		data[i] = invokeEnvBytes
+	}
+	block.Data.Data = data
+	block.Header.DataHash = block.Data.Hash()
+	ledger.blockStore.AddBlock(block)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ledger.GetBlockByNumberStripped(1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestKVLedgerBlocksBetweenTransactions(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	env1, txID1 := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	env2, txID2 := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	env1Bytes, err := proto.Marshal(env1)
+	testutil.AssertNoError(t, err, "")
+	env2Bytes, err := proto.Marshal(env2)
+	testutil.AssertNoError(t, err, "")
+
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{env1Bytes, env2Bytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.blockStore.AddBlock(block1)
+
+	sameBlockDistance, err := ledger.BlocksBetweenTransactions(txID1, txID2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, sameBlockDistance, int64(0))
+
+	env3, txID3 := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	env3Bytes, err := proto.Marshal(env3)
+	testutil.AssertNoError(t, err, "")
+	for blockNumber := uint64(2); blockNumber <= 5; blockNumber++ {
+		block := common.NewBlock(blockNumber, []byte{})
+		if blockNumber == 5 {
+			block.Data.Data = [][]byte{env3Bytes}
+		}
+		block.Header.DataHash = block.Data.Hash()
+		ledger.blockStore.AddBlock(block)
+	}
+
+	distance, err := ledger.BlocksBetweenTransactions(txID1, txID3)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, distance, int64(4))
+
+	reverseDistance, err := ledger.BlocksBetweenTransactions(txID3, txID1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, reverseDistance, int64(-4))
+
+	_, err = ledger.BlocksBetweenTransactions(txID1, "nonexistent-tx")
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerGetTransactionConfirmations(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	oldEnv, oldTxID := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	oldEnvBytes, err := proto.Marshal(oldEnv)
+	testutil.AssertNoError(t, err, "")
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{oldEnvBytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.blockStore.AddBlock(block1)
+
+	for blockNumber := uint64(2); blockNumber <= 4; blockNumber++ {
+		block := common.NewBlock(blockNumber, []byte{})
+		block.Header.DataHash = block.Data.Hash()
+		ledger.blockStore.AddBlock(block)
+	}
+
+	recentEnv, recentTxID := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	recentEnvBytes, err := proto.Marshal(recentEnv)
+	testutil.AssertNoError(t, err, "")
+	block5 := common.NewBlock(5, []byte{})
+	block5.Data.Data = [][]byte{recentEnvBytes}
+	block5.Header.DataHash = block5.Data.Hash()
+	ledger.blockStore.AddBlock(block5)
+
+	oldConfirmations, err := ledger.GetTransactionConfirmations(oldTxID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, oldConfirmations, uint64(4))
+
+	recentConfirmations, err := ledger.GetTransactionConfirmations(recentTxID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, recentConfirmations, uint64(0))
+
+	_, err = ledger.GetTransactionConfirmations("nonexistent-tx")
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerGetNextTransaction(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	env1, txID1 := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	env2, txID2 := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	env1Bytes, err := proto.Marshal(env1)
+	testutil.AssertNoError(t, err, "")
+	env2Bytes, err := proto.Marshal(env2)
+	testutil.AssertNoError(t, err, "")
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{env1Bytes, env2Bytes}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.blockStore.AddBlock(block1)
+
+	env3, txID3 := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	env3Bytes, err := proto.Marshal(env3)
+	testutil.AssertNoError(t, err, "")
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Data.Data = [][]byte{env3Bytes}
+	block2.Header.DataHash = block2.Data.Hash()
+	ledger.blockStore.AddBlock(block2)
+
+	expectedTx2, err := ledger.GetTransactionByID(txID2)
+	testutil.AssertNoError(t, err, "")
+	expectedTx3, err := ledger.GetTransactionByID(txID3)
+	testutil.AssertNoError(t, err, "")
+
+	// mid-block: tx1's successor is tx2, in the same block
+	next, err := ledger.GetNextTransaction(txID1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, next, expectedTx2)
+
+	// last-in-block: tx2's successor is tx3, the first (and only) transaction of the next block
+	next, err = ledger.GetNextTransaction(txID2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, next, expectedTx3)
+
+	// chain-final: tx3 has no successor
+	_, err = ledger.GetNextTransaction(txID3)
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+
+	_, err = ledger.GetNextTransaction("nonexistent-tx")
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerVerifyFullChain(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	viper.Set("ledger.fullChainVerification.enabled", false)
+	_, err := ledger.VerifyFullChain()
+	testutil.AssertEquals(t, err, ErrFullChainVerificationDisabled)
+
+	viper.Set("ledger.fullChainVerification.enabled", true)
+	defer viper.Set("ledger.fullChainVerification.enabled", false)
+
+	block1 := common.NewBlock(1, []byte{})
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.blockStore.AddBlock(block1)
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Header.DataHash = block2.Data.Hash()
+	ledger.blockStore.AddBlock(block2)
+	block3 := common.NewBlock(3, block2.Header.Hash())
+	block3.Header.DataHash = block3.Data.Hash()
+	ledger.blockStore.AddBlock(block3)
+
+	result, err := ledger.VerifyFullChain()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result, &ChainVerifyResult{Valid: true, BlocksVerified: 3})
+}
+
+func TestKVLedgerVerifyFullChainCorruptedBlock(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	viper.Set("ledger.fullChainVerification.enabled", true)
+	defer viper.Set("ledger.fullChainVerification.enabled", false)
+
+	block1 := common.NewBlock(1, []byte{})
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.blockStore.AddBlock(block1)
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Header.DataHash = []byte("corrupted")
+	ledger.blockStore.AddBlock(block2)
+	block3 := common.NewBlock(3, block2.Header.Hash())
+	block3.Header.DataHash = block3.Data.Hash()
+	ledger.blockStore.AddBlock(block3)
+
+	result, err := ledger.VerifyFullChain()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result, &ChainVerifyResult{Valid: false, BlocksVerified: 1, BrokenAtBlock: 2})
+}
+
+func TestKVLedgerWatchValidationFailures(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	validEnv, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	invalidEnv, invalidTxID := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	validEnvBytes, err := proto.Marshal(validEnv)
+	testutil.AssertNoError(t, err, "")
+	invalidEnvBytes, err := proto.Marshal(invalidEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{validEnvBytes, invalidEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	txsFilter := ledgerutil.NewFilterBitArray(uint(len(block.Data.Data)))
+	txsFilter.Set(1)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txsFilter.ToBytes()
+	ledger.blockStore.AddBlock(block)
+
+	cancel := make(chan struct{})
+	var failures []*ValidationResult
+	err = ledger.WatchValidationFailures(1, nil, func(result *ValidationResult) error {
+		failures = append(failures, result)
+		close(cancel)
+		return nil
+	}, cancel)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, failures, []*ValidationResult{{TxID: invalidTxID, BlockNumber: 1}})
+}
+
+func TestKVLedgerWatchValidationFailuresFiltersByChaincode(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	invalidEnv, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	invalidEnvBytes, err := proto.Marshal(invalidEnv)
+	testutil.AssertNoError(t, err, "")
+
+	block := common.NewBlock(1, []byte{})
+	block.Data.Data = [][]byte{invalidEnvBytes}
+	block.Header.DataHash = block.Data.Hash()
+	txsFilter := ledgerutil.NewFilterBitArray(uint(len(block.Data.Data)))
+	txsFilter.Set(0)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txsFilter.ToBytes()
+	ledger.blockStore.AddBlock(block)
+
+	// The only invalid transaction in the block is for "foo", not the filtered "bar", so nothing
+	// should be delivered; cancel is closed after a short delay rather than up front, since closing
+	// it up front would race with whether the block is even inspected before the iterator closes.
+	cancel := make(chan struct{})
+	time.AfterFunc(50*time.Millisecond, func() { close(cancel) })
+	var failures []*ValidationResult
+	done := make(chan error, 1)
+	go func() {
+		done <- ledger.WatchValidationFailures(1, &ValidationFailureFilter{ChaincodeID: "bar"}, func(result *ValidationResult) error {
+			failures = append(failures, result)
+			return nil
+		}, cancel)
+	}()
+	select {
+	case err = <-done:
+		testutil.AssertNoError(t, err, "")
+	case <-time.After(2 * time.Second):
+		t.Fatalf("WatchValidationFailures did not return after cancel was closed")
+	}
+	testutil.AssertEquals(t, len(failures), 0)
+}
+
+func TestKVLedgerCompareRangeTxCounts(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = [][]byte{[]byte("tx1"), []byte("tx2"), []byte("tx3")}
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.blockStore.AddBlock(block1)
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Data.Data = [][]byte{[]byte("tx4")}
+	block2.Header.DataHash = block2.Data.Hash()
+	ledger.blockStore.AddBlock(block2)
+
+	result, err := ledger.CompareRangeTxCounts(1, 1, 2, 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result, &RangeTxCountComparison{FirstRangeCount: 3, SecondRangeCount: 1, Difference: 2})
+}
+
+func TestKVLedgerStreamBlocks(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	block1 := common.NewBlock(1, []byte{})
+	block1.Header.DataHash = block1.Data.Hash()
+	ledger.blockStore.AddBlock(block1)
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Header.DataHash = block2.Data.Hash()
+	ledger.blockStore.AddBlock(block2)
+
+	cancel := make(chan struct{})
+	var streamed []uint64
+	err := ledger.StreamBlocks(1, func(block *common.Block) error {
+		streamed = append(streamed, block.Header.Number)
+		if len(streamed) == 2 {
+			// There is no block 3 yet, so without closing cancel here StreamBlocks would block
+			// forever on the underlying blocking iterator waiting for one to be committed.
+			close(cancel)
+		}
+		return nil
+	}, cancel)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, streamed, []uint64{1, 2})
+}
+
+func TestKVLedgerHasForksLinearChain(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	block1 := common.NewBlock(1, []byte{})
+	ledger.blockStore.AddBlock(block1)
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	ledger.blockStore.AddBlock(block2)
+	block3 := common.NewBlock(3, block2.Header.Hash())
+	ledger.blockStore.AddBlock(block3)
+
+	result, err := ledger.HasForks()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result, &ForkCheckResult{})
+}
+
+func TestKVLedgerHasForksBrokenLinkage(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	block1 := common.NewBlock(1, []byte{})
+	ledger.blockStore.AddBlock(block1)
+	// block2 does not link to block1's header hash, simulating a sibling block having been
+	// produced at height 2 from a different parent than the one actually stored at height 1.
+	block2 := common.NewBlock(2, []byte("sibling-parent-hash"))
+	ledger.blockStore.AddBlock(block2)
+	block3 := common.NewBlock(3, block2.Header.Hash())
+	ledger.blockStore.AddBlock(block3)
+
+	result, err := ledger.HasForks()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result, &ForkCheckResult{Forked: true, ForkedAtHeight: 2})
+}
+
+// constructEnvelopeWithTimestamp builds the marshaled bytes of an endorser transaction envelope
+// carrying a valid chaincode action payload, like constructTxEnvWithEndorsements, but with its
+// header's commit timestamp overridden to ts. GetActionFromEnvelope unconditionally extracts and
+// validates that action regardless of doMVCCValidation, so a header-only envelope with no action
+// panics the validator; stamping the timestamp onto a real envelope after construction keeps
+// validation happy while still letting tests control commit time.
+func constructEnvelopeWithTimestamp(t *testing.T, ts time.Time) []byte {
+	tsProto, err := ptypes.TimestampProto(ts)
+	testutil.AssertNoError(t, err, "")
+	env, _ := constructTxEnvWithEndorsements(t, "foo", []byte("results"), 1)
+	payload, err := putils.GetPayload(env)
+	testutil.AssertNoError(t, err, "")
+	payload.Header.ChainHeader.Timestamp = tsProto
+	payloadBytes, err := proto.Marshal(payload)
+	testutil.AssertNoError(t, err, "")
+	env.Payload = payloadBytes
+	envBytes, err := proto.Marshal(env)
+	testutil.AssertNoError(t, err, "")
+	return envBytes
+}
+
+func TestKVLedgerGetBlockWriteVolume(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	block1 := bg.NextBlock([][]byte{testutil.ConstructRandomBytes(t, 10)}, false)
+	ledger.Commit(block1)
+	block2 := bg.NextBlock([][]byte{testutil.ConstructRandomBytes(t, 20), testutil.ConstructRandomBytes(t, 30)}, false)
+	ledger.Commit(block2)
+
+	volumes, err := ledger.GetBlockWriteVolume(1, 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(volumes), 2)
+	testutil.AssertEquals(t, volumes[0], &BlockWriteVolume{BlockNumber: 1, Bytes: 10})
+	testutil.AssertEquals(t, volumes[1], &BlockWriteVolume{BlockNumber: 2, Bytes: 50})
+
+	_, err = ledger.GetBlockWriteVolume(1, maxBlockRangeSize+1)
+	testutil.AssertEquals(t, err, ErrBlockRangeTooLarge)
+}
+
+func TestKVLedgerGetKeyTouchCounts(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	rwSetFor := func(key string) []byte {
+		nsRW := &rwset.NsReadWriteSet{NameSpace: "foo", Writes: []*rwset.KVWrite{rwset.NewKVWrite(key, []byte("v"))}}
+		txRWSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{nsRW}}
+		bytes, err := txRWSet.Marshal()
+		testutil.AssertNoError(t, err, "")
+		return bytes
+	}
+
+	// "hot" is written by 3 transactions, "warm" by 2, "cold" by 1
+	envs := []string{"hot", "hot", "hot", "warm", "warm", "cold"}
+	var envBytesList [][]byte
+	for _, key := range envs {
+		txEnv, _ := constructTxEnvWithEndorsements(t, "foo", rwSetFor(key), 1)
+		envBytes, err := proto.Marshal(txEnv)
+		testutil.AssertNoError(t, err, "")
+		envBytesList = append(envBytesList, envBytes)
+	}
+
+	block1 := common.NewBlock(1, []byte{})
+	block1.Data.Data = envBytesList[:3]
+	block1.Header.DataHash = block1.Data.Hash()
+	testutil.AssertNoError(t, ledger.Commit(block1), "")
+
+	block2 := common.NewBlock(2, block1.Header.Hash())
+	block2.Data.Data = envBytesList[3:]
+	block2.Header.DataHash = block2.Data.Hash()
+	testutil.AssertNoError(t, ledger.Commit(block2), "")
+
+	touched, err := ledger.GetKeyTouchCounts(1, 2, 0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, touched, []*KeyTouchCount{
+		{ChaincodeID: "foo", Key: "hot", Count: 3},
+		{ChaincodeID: "foo", Key: "warm", Count: 2},
+		{ChaincodeID: "foo", Key: "cold", Count: 1},
+	})
+
+	top2, err := ledger.GetKeyTouchCounts(1, 2, 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, top2, []*KeyTouchCount{
+		{ChaincodeID: "foo", Key: "hot", Count: 3},
+		{ChaincodeID: "foo", Key: "warm", Count: 2},
+	})
+
+	_, err = ledger.GetKeyTouchCounts(1, maxBlockRangeSize+1, 0)
+	testutil.AssertEquals(t, err, ErrBlockRangeTooLarge)
+}
+
+func TestKVLedgerGetBlockRangeForTimeWindow(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var previousHash []byte
+	for i, offsetSeconds := range []int{0, 10, 20, 30, 40} {
+		block := common.NewBlock(uint64(i+1), previousHash)
+		block.Data.Data = [][]byte{constructEnvelopeWithTimestamp(t, base.Add(time.Duration(offsetSeconds)*time.Second))}
+		block.Header.DataHash = block.Data.Hash()
+		ledger.txtmgmt.ValidateAndPrepare(block, true)
+		ledger.blockStore.AddBlock(block)
+		previousHash = block.Header.Hash()
+	}
+	// blocks committed at offsets 0s, 10s, 20s, 30s, 40s as block numbers 1..5 respectively
+
+	blockRange, err := ledger.GetBlockRangeForTimeWindow(base.Add(10*time.Second), base.Add(31*time.Second))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, blockRange, &BlockRange{Start: 2, End: 4})
+
+	// the start of the window is inclusive, the end is exclusive
+	blockRange, err = ledger.GetBlockRangeForTimeWindow(base, base.Add(10*time.Second))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, blockRange, &BlockRange{Start: 1, End: 1})
+
+	blockRange, err = ledger.GetBlockRangeForTimeWindow(base.Add(100*time.Second), base.Add(200*time.Second))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, blockRange, &BlockRange{})
+}
+
+func TestKVLedgerGetFirstBlockAfter(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var previousHash []byte
+	for i, offsetSeconds := range []int{0, 10, 20, 30, 40} {
+		block := common.NewBlock(uint64(i+1), previousHash)
+		block.Data.Data = [][]byte{constructEnvelopeWithTimestamp(t, base.Add(time.Duration(offsetSeconds)*time.Second))}
+		block.Header.DataHash = block.Data.Hash()
+		ledger.txtmgmt.ValidateAndPrepare(block, true)
+		ledger.blockStore.AddBlock(block)
+		previousHash = block.Header.Hash()
+	}
+	// blocks committed at offsets 0s, 10s, 20s, 30s, 40s as block numbers 1..5 respectively
+
+	block, err := ledger.GetFirstBlockAfter(base.Add(-10 * time.Second))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, block.Header.Number, uint64(1))
+	testutil.AssertEquals(t, len(block.Data.Data), 0)
+
+	block, err = ledger.GetFirstBlockAfter(base.Add(21 * time.Second))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, block.Header.Number, uint64(4))
+
+	_, err = ledger.GetFirstBlockAfter(base.Add(100 * time.Second))
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerGetBlockTimestamp(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	bg := testutil.NewBlockGenerator(t)
+	block1 := bg.NextBlock([][]byte{simRes}, false)
+	ledger.Commit(block1)
+
+	expectedTimestamp, err := firstTxTimestamp(block1)
+	testutil.AssertNoError(t, err, "")
+
+	ts, err := ledger.GetBlockTimestamp(1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, ts, expectedTimestamp)
+
+	_, err = ledger.GetBlockTimestamp(2)
+	testutil.AssertEquals(t, err, coreledger.ErrNotFound)
+}
+
+func TestKVLedgerGetLatestBlockTxIDs(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	_, err := ledger.GetLatestBlockTxIDs()
+	testutil.AssertEquals(t, err, ErrEmptyBlockchain)
+
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	bg := testutil.NewBlockGenerator(t)
+	block1 := bg.NextBlock([][]byte{simRes}, false)
+	ledger.Commit(block1)
+
+	txIDs, err := ledger.GetLatestBlockTxIDs()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(txIDs), len(block1.Data.Data))
+
+	block2 := bg.NextTestBlock(2, 10)
+	ledger.txtmgmt.ValidateAndPrepare(block2, true)
+	ledger.blockStore.AddBlock(block2)
+
+	txIDs, err = ledger.GetLatestBlockTxIDs()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(txIDs), 2)
+}
+
+func TestKVLedgerBackupBlocks(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	var committed []*common.Block
+	for i := 0; i < 3; i++ {
+		block := bg.NextTestBlock(1, 10)
+		ledger.Commit(block)
+		committed = append(committed, block)
+	}
+
+	buf := &bytes.Buffer{}
+	err := ledger.BackupBlocks(0, buf, nil)
+	testutil.AssertNoError(t, err, "")
+
+	checksum := crc32.NewIEEE()
+	var recovered []*common.Block
+	for _, expected := range committed {
+		lenBuf := make([]byte, 4)
+		_, err := io.ReadFull(buf, lenBuf)
+		testutil.AssertNoError(t, err, "")
+		checksum.Write(lenBuf)
+		blockBytes := make([]byte, binary.BigEndian.Uint32(lenBuf))
+		_, err = io.ReadFull(buf, blockBytes)
+		testutil.AssertNoError(t, err, "")
+		checksum.Write(blockBytes)
+		block := &common.Block{}
+		testutil.AssertNoError(t, proto.Unmarshal(blockBytes, block), "")
+		recovered = append(recovered, block)
+		testutil.AssertEquals(t, block.Header.Number, expected.Header.Number)
+	}
+
+	checksumBuf := make([]byte, 4)
+	_, err = io.ReadFull(buf, checksumBuf)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, binary.BigEndian.Uint32(checksumBuf), checksum.Sum32())
+	testutil.AssertEquals(t, len(recovered), len(committed))
+
+	cancel := make(chan struct{})
+	close(cancel)
+	err = ledger.BackupBlocks(0, &bytes.Buffer{}, cancel)
+	testutil.AssertError(t, err, "")
+}
+
+func TestKVLedgerResolveTxPrefix(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	block1 := bg.NextTestBlock(1, 10)
+	ledger.Commit(block1)
+
+	txIDs, err := ledger.GetLatestBlockTxIDs()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(txIDs), 1)
+
+	uniquePrefix := txIDs[0][:len(txIDs[0])/2]
+	resolved, err := ledger.ResolveTxPrefix(uniquePrefix)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, resolved, txIDs)
+
+	_, err = ledger.ResolveTxPrefix("a")
+	testutil.AssertEquals(t, err, ErrTxIDPrefixTooShort)
+}
+
+func TestKVLedgerGetTransactionCost(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	bg := testutil.NewBlockGenerator(t)
+	block1 := bg.NextBlock([][]byte{simRes}, false)
+	ledger.Commit(block1)
+
+	txIDs, err := ledger.GetLatestBlockTxIDs()
+	testutil.AssertNoError(t, err, "")
+
+	cost, err := ledger.GetTransactionCost(txIDs[0])
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, cost.Tracked, false)
+
+	_, err = ledger.GetTransactionCost("non-existent-txid")
+	testutil.AssertError(t, err, "")
+}
+
+func TestKVLedgerVerifyCommitOrder(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 3; i++ {
+		block := bg.NextTestBlock(1, 10)
+		ledger.Commit(block)
+	}
+
+	anomaly, err := ledger.VerifyCommitOrder(1, 3)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, anomaly)
+
+	_, err = ledger.VerifyCommitOrder(3, 1)
+	testutil.AssertError(t, err, "")
+}
+
+func TestKVLedgerGetCommitRateTrend(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 3; i++ {
+		block := bg.NextTestBlock(2, 10)
+		ledger.Commit(block)
+	}
+
+	// all three blocks commit within the same (large) bucket, since they're committed back-to-back
+	trend, err := ledger.GetCommitRateTrend(1, 3, time.Hour)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(trend), 1)
+	testutil.AssertEquals(t, trend[0].BlocksCommitted, uint64(3))
+	testutil.AssertEquals(t, trend[0].TransactionsCommitted, uint64(6))
+
+	_, err = ledger.GetCommitRateTrend(3, 1, time.Hour)
+	testutil.AssertError(t, err, "")
+
+	_, err = ledger.GetCommitRateTrend(1, 3, 0)
+	testutil.AssertError(t, err, "")
+}
+
+func TestKVLedgerFindBlockBursts(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 4; i++ {
+		block := bg.NextTestBlock(1, 10)
+		ledger.Commit(block)
+	}
+
+	// all 4 blocks commit back-to-back, well within a one-hour window: one burst covering all of them
+	bursts, err := ledger.FindBlockBursts(1, 4, time.Hour)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, bursts, []*BlockBurst{{StartBlockNumber: 1, EndBlockNumber: 4, BlockCount: 4}})
+
+	_, err = ledger.FindBlockBursts(4, 1, time.Hour)
+	testutil.AssertError(t, err, "")
+
+	_, err = ledger.FindBlockBursts(1, 4, 0)
+	testutil.AssertError(t, err, "")
+}
+
+func TestKVLedgerGetChainStatistics(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	stats, err := ledger.GetChainStatistics()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, stats.Height, uint64(0))
+
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 2; i++ {
+		block := bg.NextTestBlock(3, 10)
+		ledger.Commit(block)
+	}
+
+	stats, err = ledger.GetChainStatistics()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, stats.Height, uint64(2))
+	testutil.AssertEquals(t, stats.TotalTransactions, uint64(6))
+}
+
+func TestKVLedgerGetStorageBreakdown(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 3; i++ {
+		block := bg.NextTestBlock(2, 10)
+		ledger.Commit(block)
+	}
+
+	breakdown, err := ledger.GetStorageBreakdown()
+	testutil.AssertNoError(t, err, "")
+	if breakdown.BlockStoreBytes == 0 {
+		t.Fatal("expected a non-zero block store size after committing blocks")
+	}
+	if breakdown.StateDBUnsupported {
+		t.Fatal("expected the goleveldb-backed state database to be reported as supported")
+	}
+	if !breakdown.HistoryDBUnsupported {
+		t.Fatal("expected the history database to be reported as unsupported when disabled")
+	}
+}
+
+func TestKVLedgerReplayBlocks(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 3; i++ {
+		block := bg.NextTestBlock(1, 10)
+		ledger.Commit(block)
+	}
+
+	var replayed []uint64
+	err := ledger.ReplayBlocks(1, 3, func(block *common.Block) error {
+		replayed = append(replayed, block.Header.Number)
+		return nil
+	})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, replayed, []uint64{1, 2, 3})
+
+	err = ledger.ReplayBlocks(1, 100, func(block *common.Block) error { return nil })
+	testutil.AssertError(t, err, "")
+
+	expectedErr := errors.New("stop here")
+	err = ledger.ReplayBlocks(1, 3, func(block *common.Block) error { return expectedErr })
+	testutil.AssertEquals(t, err, expectedErr)
+}
+
 func TestKVLedgerStateDBRecovery(t *testing.T) {
 	env := newTestEnv(t)
 	defer env.cleanup()
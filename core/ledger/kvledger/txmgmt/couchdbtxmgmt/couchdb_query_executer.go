@@ -35,6 +35,18 @@ func (q *CouchDBQueryExecutor) GetState(ns string, key string) ([]byte, error) {
 	return value, nil
 }
 
+// GetStateWithVersion implements method in interface `ledger.QueryExecutor`
+func (q *CouchDBQueryExecutor) GetStateWithVersion(ns string, key string) (*ledger.VersionedValue, error) {
+	value, ver, err := q.txmgr.getCommittedValueAndVersion(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, ledger.ErrNotFound
+	}
+	return &ledger.VersionedValue{Value: value, Height: &ledger.Height{BlockNum: ver.BlockNum, TxNum: ver.TxNum}}, nil
+}
+
 // GetStateMultipleKeys implements method in interface `ledger.QueryExecutor`
 func (q *CouchDBQueryExecutor) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
 	var results [][]byte
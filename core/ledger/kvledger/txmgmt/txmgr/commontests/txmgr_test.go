@@ -414,6 +414,53 @@ func testGetSetMultipeKeys(t *testing.T, env testEnv) {
 	}
 }
 
+func TestGetStateWithVersion(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Logf("Running test for TestEnv = %s", testEnv.getName())
+		testEnv.init(t)
+		testGetStateWithVersion(t, testEnv)
+		testEnv.cleanup()
+	}
+}
+
+func testGetStateWithVersion(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+
+	qe, _ := txMgr.NewQueryExecutor()
+	_, err := qe.GetStateWithVersion("ns1", "key1")
+	testutil.AssertEquals(t, err, ledger.ErrNotFound)
+	qe.Done()
+
+	// tx1 commits key1 in block 1
+	s1, _ := txMgr.NewTxSimulator()
+	s1.SetState("ns1", "key1", []byte("value1"))
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet1)
+
+	qe, _ = txMgr.NewQueryExecutor()
+	vv, err := qe.GetStateWithVersion("ns1", "key1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, vv.Value, []byte("value1"))
+	testutil.AssertEquals(t, vv.Height, &ledger.Height{BlockNum: 1, TxNum: 1})
+	qe.Done()
+
+	// tx2 updates key1 in block 2
+	s2, _ := txMgr.NewTxSimulator()
+	s2.SetState("ns1", "key1", []byte("value2"))
+	s2.Done()
+	txRWSet2, _ := s2.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet2)
+
+	qe, _ = txMgr.NewQueryExecutor()
+	defer qe.Done()
+	vv, err = qe.GetStateWithVersion("ns1", "key1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, vv.Value, []byte("value2"))
+	testutil.AssertEquals(t, vv.Height, &ledger.Height{BlockNum: 2, TxNum: 1})
+}
+
 func createTestKey(i int) string {
 	if i == 0 {
 		return ""
@@ -42,6 +42,24 @@ func (h *queryHelper) getState(ns string, key string) ([]byte, error) {
 	return val, nil
 }
 
+func (h *queryHelper) getStateWithVersion(ns string, key string) (*ledger.VersionedValue, error) {
+	h.checkDone()
+	versionedValue, err := h.txmgr.db.GetState(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	if versionedValue == nil {
+		return nil, ledger.ErrNotFound
+	}
+	if h.rwset != nil {
+		h.rwset.AddToReadSet(ns, key, versionedValue.Version)
+	}
+	return &ledger.VersionedValue{
+		Value:  versionedValue.Value,
+		Height: &ledger.Height{BlockNum: versionedValue.Version.BlockNum, TxNum: versionedValue.Version.TxNum},
+	}, nil
+}
+
 func (h *queryHelper) getStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
 	h.checkDone()
 	versionedValues, err := h.txmgr.db.GetStateMultipleKeys(namespace, keys)
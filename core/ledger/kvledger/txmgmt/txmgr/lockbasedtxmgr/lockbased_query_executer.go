@@ -39,6 +39,11 @@ func (q *lockBasedQueryExecutor) GetState(ns string, key string) ([]byte, error)
 	return q.helper.getState(ns, key)
 }
 
+// GetStateWithVersion implements method in interface `ledger.QueryExecutor`
+func (q *lockBasedQueryExecutor) GetStateWithVersion(ns string, key string) (*ledger.VersionedValue, error) {
+	return q.helper.getStateWithVersion(ns, key)
+}
+
 // GetStateMultipleKeys implements method in interface `ledger.QueryExecutor`
 func (q *lockBasedQueryExecutor) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
 	return q.helper.getStateMultipleKeys(namespace, keys)
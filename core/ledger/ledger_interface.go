@@ -17,10 +17,15 @@ limitations under the License.
 package ledger
 
 import (
+	"errors"
+
 	"github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
+// ErrNotFound is returned when a query targets a key that does not exist in the ledger
+var ErrNotFound = errors.New("Entry not found in ledger")
+
 // Ledger captures the methods that are common across the 'raw ledger' and the 'final ledger'
 type Ledger interface {
 	// GetBlockchainInfo returns basic info about blockchain
@@ -77,6 +82,10 @@ type ValidatedLedger interface {
 type QueryExecutor interface {
 	// GetState gets the value for given namespace and key. For a chaincode, the namespace corresponds to the chaincodeId
 	GetState(namespace string, key string) ([]byte, error)
+	// GetStateWithVersion gets the value for given namespace and key along with the height (committing block
+	// number and transaction number) at which it was last written. This allows optimistic-concurrency clients
+	// to detect conflicts. Returns ErrNotFound if the key does not exist.
+	GetStateWithVersion(namespace string, key string) (*VersionedValue, error)
 	// GetStateMultipleKeys gets the values for multiple keys in a single call
 	GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error)
 	// GetStateRangeScanIterator returns an iterator that contains all the key-values between given key ranges.
@@ -93,6 +102,20 @@ type QueryExecutor interface {
 type HistoryQueryExecutor interface {
 	// GetTransactionsForKey retrieves the set of transactons that updated this key by doing a key range query.
 	GetTransactionsForKey(namespace string, key string, includeValues bool, includeTransactions bool) (ResultsIterator, error)
+	// GetTransactionsForKeyPaged behaves like GetTransactionsForKey but returns at most pageSize
+	// historical versions (a non-positive pageSize falls back to an implementation-chosen default),
+	// resuming from the given bookmark; the empty string starts from the beginning. Alongside the
+	// iterator it returns a bookmark to pass on the next call, which is empty once the final page
+	// has been reached. Intended for a key with too many historical versions to return in one call.
+	GetTransactionsForKeyPaged(namespace string, key string, includeValues bool, pageSize int, bookmark string) (ResultsIterator, string, error)
+	// CountAllKeysEver returns the number of distinct keys that have ever been written in the given
+	// namespace, including keys that have since been deleted. This is a churn metric, not a count of
+	// keys that currently exist - use a QueryExecutor range scan for the latter.
+	CountAllKeysEver(namespace string) (uint64, error)
+	// MostVersionedKey returns the key in the given namespace with the highest number of historical
+	// versions (write events) and that count, for hotspot/churn analysis. Returns an empty key and
+	// zero count for a namespace with no recorded history.
+	MostVersionedKey(namespace string) (string, uint64, error)
 }
 
 // TxSimulator simulates a transaction on a consistent snapshot of the 'as recent state as possible'
@@ -136,6 +159,18 @@ type KV struct {
 	Value []byte
 }
 
+// Height identifies a transaction by the block number it was committed in and its 1-based position within that block
+type Height struct {
+	BlockNum uint64
+	TxNum    uint64
+}
+
+// VersionedValue encloses a state value along with the Height at which it was last written
+type VersionedValue struct {
+	Value  []byte
+	Height *Height
+}
+
 // KeyModification - QueryResult for History.
 type KeyModification struct {
 	TxID        string
@@ -50,6 +50,33 @@ func GetCouchDBDefinition() *CouchDBDef {
 	return &CouchDBDef{couchDBAddress, username, password}
 }
 
+//GetHistoryQueryLimit returns the default number of historical versions returned by a single page
+//of a paginated history query, from ledger.history.queryLimit. Falls back to 1000, the limit that
+//was previously hardcoded before pagination existed, when unset.
+func GetHistoryQueryLimit() int {
+	limit := viper.GetInt("ledger.history.queryLimit")
+	if limit <= 0 {
+		return 1000
+	}
+	return limit
+}
+
+//GetRangeQueryLimit returns the maximum number of key/value pairs a single range-scan style state
+//query is allowed to return, from ledger.state.rangeQueryLimit. Falls back to 1000 when unset.
+func GetRangeQueryLimit() int {
+	limit := viper.GetInt("ledger.state.rangeQueryLimit")
+	if limit <= 0 {
+		return 1000
+	}
+	return limit
+}
+
+//IsFullChainVerificationEnabled reports whether an expensive genesis-to-tip chain integrity sweep
+//(re-hashing every block) is permitted to run. Disabled by default since it is costly on a long chain.
+func IsFullChainVerificationEnabled() bool {
+	return viper.GetBool("ledger.fullChainVerification.enabled")
+}
+
 //IsHistoryDBEnabled exposes the historyDatabase variable
 //History database can only be enabled if couchDb is enabled
 //as it the history stored in the same couchDB instance.
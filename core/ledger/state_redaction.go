@@ -0,0 +1,121 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+// StateRedactor masks or drops a state value before it is returned to a caller of QueryExecutor.
+// It receives the namespace (the chaincode id the value belongs to), the key, and the value as
+// stored, and returns the value to surface in its place. Returning nil drops the value.
+type StateRedactor func(namespace, key string, value []byte) []byte
+
+var stateRedactors []StateRedactor
+
+// RegisterStateRedactor adds a redactor that is run over the results of GetState, GetStateMultipleKeys
+// and GetStateRangeScanIterator on every QueryExecutor obtained from NewRedactingQueryExecutor, letting
+// embedders mask or drop values - for example PII - before they reach a client. Redactors run in
+// registration order, each seeing the value produced by the one before it, and are applied before
+// serialization for the response. RegisterStateRedactor is expected to be called during process
+// initialization, before any QueryExecutor is constructed; it is not safe for concurrent use with
+// queries in flight.
+func RegisterStateRedactor(redactor StateRedactor) {
+	stateRedactors = append(stateRedactors, redactor)
+}
+
+func redactValue(namespace, key string, value []byte) []byte {
+	for _, redactor := range stateRedactors {
+		value = redactor(namespace, key, value)
+	}
+	return value
+}
+
+// NewRedactingQueryExecutor wraps qe so that GetState, GetStateMultipleKeys and
+// GetStateRangeScanIterator run their results through the redactors registered via
+// RegisterStateRedactor before returning them to the caller.
+func NewRedactingQueryExecutor(qe QueryExecutor) QueryExecutor {
+	return &redactingQueryExecutor{qe}
+}
+
+type redactingQueryExecutor struct {
+	qe QueryExecutor
+}
+
+func (r *redactingQueryExecutor) GetState(namespace string, key string) ([]byte, error) {
+	value, err := r.qe.GetState(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	return redactValue(namespace, key, value), nil
+}
+
+func (r *redactingQueryExecutor) GetStateWithVersion(namespace string, key string) (*VersionedValue, error) {
+	versionedValue, err := r.qe.GetStateWithVersion(namespace, key)
+	if err != nil || versionedValue == nil {
+		return versionedValue, err
+	}
+	return &VersionedValue{Value: redactValue(namespace, key, versionedValue.Value), Height: versionedValue.Height}, nil
+}
+
+func (r *redactingQueryExecutor) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
+	values, err := r.qe.GetStateMultipleKeys(namespace, keys)
+	if err != nil {
+		return nil, err
+	}
+	redacted := make([][]byte, len(values))
+	for i, value := range values {
+		redacted[i] = redactValue(namespace, keys[i], value)
+	}
+	return redacted, nil
+}
+
+func (r *redactingQueryExecutor) GetStateRangeScanIterator(namespace string, startKey string, endKey string) (ResultsIterator, error) {
+	itr, err := r.qe.GetStateRangeScanIterator(namespace, startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	return &redactingResultsIterator{itr, namespace}, nil
+}
+
+func (r *redactingQueryExecutor) ExecuteQuery(query string) (ResultsIterator, error) {
+	return r.qe.ExecuteQuery(query)
+}
+
+func (r *redactingQueryExecutor) Done() {
+	r.qe.Done()
+}
+
+// redactingResultsIterator redacts the Value of each *KV result returned by the wrapped iterator.
+// Results of any other type (e.g. from ExecuteQuery's rich-query iterators) are passed through
+// unmodified, since the redaction contract is specific to the KV-based data model.
+type redactingResultsIterator struct {
+	itr       ResultsIterator
+	namespace string
+}
+
+func (r *redactingResultsIterator) Next() (QueryResult, error) {
+	queryResult, err := r.itr.Next()
+	if err != nil || queryResult == nil {
+		return queryResult, err
+	}
+	kv, ok := queryResult.(*KV)
+	if !ok {
+		return queryResult, nil
+	}
+	return &KV{Key: kv.Key, Value: redactValue(r.namespace, kv.Key, kv.Value)}, nil
+}
+
+func (r *redactingResultsIterator) Close() {
+	r.itr.Close()
+}
@@ -0,0 +1,140 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import "testing"
+
+// fakeQueryExecutor is a minimal QueryExecutor backed by an in-memory namespace/key map, used to
+// test that NewRedactingQueryExecutor applies registered redactors without exercising a real state DB.
+type fakeQueryExecutor struct {
+	state map[string]map[string][]byte
+}
+
+func (f *fakeQueryExecutor) GetState(namespace string, key string) ([]byte, error) {
+	return f.state[namespace][key], nil
+}
+
+func (f *fakeQueryExecutor) GetStateWithVersion(namespace string, key string) (*VersionedValue, error) {
+	value, ok := f.state[namespace][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &VersionedValue{Value: value, Height: &Height{BlockNum: 1, TxNum: 1}}, nil
+}
+
+func (f *fakeQueryExecutor) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = f.state[namespace][key]
+	}
+	return values, nil
+}
+
+func (f *fakeQueryExecutor) GetStateRangeScanIterator(namespace string, startKey string, endKey string) (ResultsIterator, error) {
+	kvs := []*KV{}
+	for key, value := range f.state[namespace] {
+		if (startKey == "" || key >= startKey) && (endKey == "" || key < endKey) {
+			kvs = append(kvs, &KV{Key: key, Value: value})
+		}
+	}
+	return &fakeResultsIterator{kvs: kvs}, nil
+}
+
+func (f *fakeQueryExecutor) ExecuteQuery(query string) (ResultsIterator, error) {
+	return &fakeResultsIterator{}, nil
+}
+
+func (f *fakeQueryExecutor) Done() {}
+
+type fakeResultsIterator struct {
+	kvs []*KV
+	pos int
+}
+
+func (f *fakeResultsIterator) Next() (QueryResult, error) {
+	if f.pos >= len(f.kvs) {
+		return nil, nil
+	}
+	kv := f.kvs[f.pos]
+	f.pos++
+	return kv, nil
+}
+
+func (f *fakeResultsIterator) Close() {}
+
+func maskSSN(namespace, key string, value []byte) []byte {
+	if key == "ssn" {
+		return []byte("***REDACTED***")
+	}
+	return value
+}
+
+func TestRegisterStateRedactor(t *testing.T) {
+	stateRedactors = nil
+	defer func() { stateRedactors = nil }()
+	RegisterStateRedactor(maskSSN)
+
+	inner := &fakeQueryExecutor{state: map[string]map[string][]byte{
+		"ns1": {"ssn": []byte("123-45-6789"), "name": []byte("alice")},
+	}}
+	qe := NewRedactingQueryExecutor(inner)
+
+	value, err := qe.GetState("ns1", "ssn")
+	if err != nil {
+		t.Fatalf("GetState returned an error: %s", err)
+	}
+	if string(value) != "***REDACTED***" {
+		t.Errorf("Expected ssn to be redacted, got %s", value)
+	}
+
+	value, err = qe.GetState("ns1", "name")
+	if err != nil {
+		t.Fatalf("GetState returned an error: %s", err)
+	}
+	if string(value) != "alice" {
+		t.Errorf("Expected name to pass through unredacted, got %s", value)
+	}
+
+	values, err := qe.GetStateMultipleKeys("ns1", []string{"ssn", "name"})
+	if err != nil {
+		t.Fatalf("GetStateMultipleKeys returned an error: %s", err)
+	}
+	if string(values[0]) != "***REDACTED***" || string(values[1]) != "alice" {
+		t.Errorf("Expected only ssn to be redacted in batch result, got %v", values)
+	}
+
+	itr, err := qe.GetStateRangeScanIterator("ns1", "", "")
+	if err != nil {
+		t.Fatalf("GetStateRangeScanIterator returned an error: %s", err)
+	}
+	seen := map[string]string{}
+	for {
+		res, err := itr.Next()
+		if err != nil {
+			t.Fatalf("Next returned an error: %s", err)
+		}
+		if res == nil {
+			break
+		}
+		kv := res.(*KV)
+		seen[kv.Key] = string(kv.Value)
+	}
+	itr.Close()
+	if seen["ssn"] != "***REDACTED***" || seen["name"] != "alice" {
+		t.Errorf("Expected range scan to redact only ssn, got %v", seen)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import "bytes"
+
+// VerifyResult reports the outcome of VerifyStateValue.
+type VerifyResult struct {
+	// Matches is true when the supplied value equals the currently committed value.
+	Matches bool
+	// CurrentVersion is the height at which the currently committed value was last written. It is
+	// nil when the supplied value matches, or when the key does not currently exist.
+	CurrentVersion *Height
+}
+
+// VerifyStateValue compares a client-supplied (namespace, key, value) against the value currently
+// committed in the ledger, so that a light client holding a cached value can confirm it is still
+// current without fetching and comparing the value itself.
+func VerifyStateValue(qe QueryExecutor, namespace string, key string, value []byte) (*VerifyResult, error) {
+	current, err := qe.GetStateWithVersion(namespace, key)
+	if err == ErrNotFound {
+		return &VerifyResult{Matches: false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(current.Value, value) {
+		return &VerifyResult{Matches: true}, nil
+	}
+	return &VerifyResult{Matches: false, CurrentVersion: current.Height}, nil
+}
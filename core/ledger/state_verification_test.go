@@ -0,0 +1,49 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import "testing"
+
+func TestVerifyStateValue(t *testing.T) {
+	qe := &fakeQueryExecutor{state: map[string]map[string][]byte{
+		"ns1": {"key1": []byte("value1")},
+	}}
+
+	result, err := VerifyStateValue(qe, "ns1", "key1", []byte("value1"))
+	if err != nil {
+		t.Fatalf("VerifyStateValue returned an error: %s", err)
+	}
+	if !result.Matches || result.CurrentVersion != nil {
+		t.Errorf("Expected a matching value with no CurrentVersion, got %+v", result)
+	}
+
+	result, err = VerifyStateValue(qe, "ns1", "key1", []byte("stale-value"))
+	if err != nil {
+		t.Fatalf("VerifyStateValue returned an error: %s", err)
+	}
+	if result.Matches || result.CurrentVersion == nil {
+		t.Errorf("Expected a stale value with a CurrentVersion, got %+v", result)
+	}
+
+	result, err = VerifyStateValue(qe, "ns1", "absentkey", []byte("anything"))
+	if err != nil {
+		t.Fatalf("VerifyStateValue returned an error: %s", err)
+	}
+	if result.Matches || result.CurrentVersion != nil {
+		t.Errorf("Expected an absent key to not match and have no CurrentVersion, got %+v", result)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+// BloomFilter is a space-efficient probabilistic set membership structure built on top of
+// FilterBitArray: Add never false-negatives, and Test's false-positive rate converges to the rate
+// the filter was sized for as long as no more than the expected number of items are added to it.
+type BloomFilter struct {
+	bits FilterBitArray
+	k    uint
+}
+
+// NewBloomFilter returns an empty BloomFilter sized to hold expectedItems items at approximately
+// falsePositiveRate false positives, using the standard optimal-size and optimal-hash-count
+// formulas. An expectedItems below 1 is raised to 1, and a falsePositiveRate outside (0, 1) is
+// replaced by 0.01, so a degenerate caller still gets a (tiny) working filter rather than a
+// division by zero.
+func NewBloomFilter(expectedItems int, falsePositiveRate float64) *BloomFilter {
+	if expectedItems < 1 {
+		expectedItems = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+	n := float64(expectedItems)
+	m := uint(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m < 1 {
+		m = 1
+	}
+	k := uint(math.Round((float64(m) / n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{bits: NewFilterBitArray(m), k: k}
+}
+
+// NewBloomFilterFromBytes reconstructs a BloomFilter previously serialized with ToBytes, for testing
+// membership against a filter computed elsewhere without recomputing it.
+func NewBloomFilterFromBytes(bits []byte, k uint) *BloomFilter {
+	return &BloomFilter{bits: NewFilterBitArrayFromBytes(bits), k: k}
+}
+
+// Add records item as a member of the set.
+func (f *BloomFilter) Add(item []byte) {
+	capacity := uint64(f.bits.Capacity())
+	for i := uint(0); i < f.k; i++ {
+		f.bits.Set(uint(bloomHash(item, i) % capacity))
+	}
+}
+
+// Test reports whether item may be a member of the set. A false return is certain; a true return is
+// only probabilistic, as governed by the false-positive rate the filter was sized for.
+func (f *BloomFilter) Test(item []byte) bool {
+	capacity := uint64(f.bits.Capacity())
+	for i := uint(0); i < f.k; i++ {
+		if !f.bits.IsSet(uint(bloomHash(item, i) % capacity)) {
+			return false
+		}
+	}
+	return true
+}
+
+// K returns the number of hash functions the filter was built with, needed alongside ToBytes to
+// reconstruct it with NewBloomFilterFromBytes.
+func (f *BloomFilter) K() uint {
+	return f.k
+}
+
+// ToBytes returns the filter's underlying bit array for storage or transmission.
+func (f *BloomFilter) ToBytes() []byte {
+	return f.bits.ToBytes()
+}
+
+// bloomHash derives the i'th of a BloomFilter's k hashes of item. Rather than combine two hashes of
+// item via Kirsch-Mitzenmacher double hashing (h1 + i*h2), which this package's earlier fnv-1a-32/64
+// implementation showed to produce correlated probe positions for a given item (since both hashes
+// summarize the same bytes via near-identical algorithms) and consequently a false-positive rate
+// several times the configured one, each hash is computed independently by feeding i ahead of item
+// into fnv-1a-64: fnv-1a only fully diffuses a byte into the running hash once more bytes follow it,
+// so seeding with i first (rather than appending it after item) ensures every hash bit is mixed by
+// the whole of item instead of leaving i's influence confined to a handful of weakly-diffused bits.
+func bloomHash(item []byte, i uint) uint64 {
+	h := fnv.New64a()
+	var seed [4]byte
+	binary.BigEndian.PutUint32(seed[:], uint32(i))
+	h.Write(seed[:])
+	h.Write(item)
+	return h.Sum64()
+}
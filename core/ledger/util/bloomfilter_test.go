@@ -0,0 +1,71 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomFilterKnownItemsTestPositive(t *testing.T) {
+	items := make([][]byte, 1000)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("tx-%d", i))
+	}
+
+	filter := NewBloomFilter(len(items), 0.01)
+	for _, item := range items {
+		filter.Add(item)
+	}
+
+	for _, item := range items {
+		assert.True(t, filter.Test(item), "expected a known item to test positive")
+	}
+}
+
+func TestBloomFilterRandomItemMostlyTestsNegative(t *testing.T) {
+	items := make([][]byte, 1000)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("tx-%d", i))
+	}
+
+	filter := NewBloomFilter(len(items), 0.01)
+	for _, item := range items {
+		filter.Add(item)
+	}
+
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if filter.Test([]byte(fmt.Sprintf("absent-%d", i))) {
+			falsePositives++
+		}
+	}
+	// sized for a 1% false-positive rate; allow some headroom over that so the test isn't flaky
+	assert.True(t, falsePositives < 30, "expected close to a 1%% false-positive rate, got %d/1000", falsePositives)
+}
+
+func TestBloomFilterRoundTripsThroughBytes(t *testing.T) {
+	filter := NewBloomFilter(10, 0.01)
+	filter.Add([]byte("tx-1"))
+	filter.Add([]byte("tx-2"))
+
+	reconstructed := NewBloomFilterFromBytes(filter.ToBytes(), filter.K())
+	assert.True(t, reconstructed.Test([]byte("tx-1")))
+	assert.True(t, reconstructed.Test([]byte("tx-2")))
+}
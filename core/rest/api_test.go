@@ -0,0 +1,140 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestNewOpenchainServerDefaultDialTimeout(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if server.validatorDialTimeout != defaultValidatorDialTimeout {
+		t.Fatalf("expected default dial timeout %s, got %s", defaultValidatorDialTimeout, server.validatorDialTimeout)
+	}
+}
+
+func TestNewOpenchainServerConfiguredDialTimeout(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.validatorDialTimeout", 10*time.Second)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if server.validatorDialTimeout != 10*time.Second {
+		t.Fatalf("expected configured dial timeout 10s, got %s", server.validatorDialTimeout)
+	}
+}
+
+func TestNewOpenchainServerRejectsNonPositiveDialTimeout(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.validatorDialTimeout", 0)
+
+	if _, err := NewOpenchainServer(); err == nil {
+		t.Fatalf("expected an error for a zero dial timeout")
+	}
+
+	viper.Set("rest.validatorDialTimeout", -time.Second)
+	if _, err := NewOpenchainServer(); err == nil {
+		t.Fatalf("expected an error for a negative dial timeout")
+	}
+}
+
+func TestValidatorKeepaliveTimeDefaultsAndOverrides(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	if validatorKeepaliveTime() != defaultValidatorKeepaliveTime {
+		t.Fatalf("expected default keepalive time %s, got %s", defaultValidatorKeepaliveTime, validatorKeepaliveTime())
+	}
+	if validatorKeepaliveTimeout() != defaultValidatorKeepaliveTimeout {
+		t.Fatalf("expected default keepalive timeout %s, got %s", defaultValidatorKeepaliveTimeout, validatorKeepaliveTimeout())
+	}
+
+	viper.Set("rest.validatorKeepaliveTime", 15*time.Second)
+	viper.Set("rest.validatorKeepaliveTimeout", 5*time.Second)
+	if validatorKeepaliveTime() != 15*time.Second {
+		t.Fatalf("expected configured keepalive time 15s, got %s", validatorKeepaliveTime())
+	}
+	if validatorKeepaliveTimeout() != 5*time.Second {
+		t.Fatalf("expected configured keepalive timeout 5s, got %s", validatorKeepaliveTimeout())
+	}
+}
+
+func TestKeepaliveDialOptionIsNotNil(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	if keepaliveDialOption() == nil {
+		t.Fatalf("expected a non-nil dial option")
+	}
+}
+
+func TestDialOptionsTLSDisabled(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("peer.tls.enabled", false)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := server.dialOptions(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestDialOptionsTLSEnabledWithRootCert(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("peer.tls.enabled", true)
+	viper.Set("peer.tls.rootcert.file", "../../msp/sampleconfig/cacerts/cacert.pem")
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := server.dialOptions(); err != nil {
+		t.Fatalf("unexpected error building TLS dial option: %s", err)
+	}
+}
+
+func TestDialOptionsTLSEnabledMissingCertFile(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("peer.tls.enabled", true)
+	viper.Set("peer.tls.rootcert.file", "does-not-exist.pem")
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := server.dialOptions(); err == nil {
+		t.Fatalf("expected an error for a missing cert file")
+	}
+}
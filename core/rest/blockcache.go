@@ -0,0 +1,99 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// defaultBlockCacheSize is used when rest.blockCacheSize is unset in configuration.
+const defaultBlockCacheSize = 1000
+
+// blockCache is a size-bounded, concurrency-safe least-recently-used cache of committed blocks,
+// keyed by block number. Blocks below the current chain height never change, so once a block is
+// cached it is never invalidated; eviction only ever happens to make room for a more recently
+// fetched block.
+type blockCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[uint64]*list.Element
+	hits     uint64
+	misses   uint64
+}
+
+type blockCacheEntry struct {
+	number uint64
+	block  *common.Block
+}
+
+// newBlockCache constructs a blockCache holding at most capacity blocks. A non-positive capacity
+// disables caching: get always misses and put is a no-op.
+func newBlockCache(capacity int) *blockCache {
+	return &blockCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    map[uint64]*list.Element{},
+	}
+}
+
+// get returns the cached block for number, if present, promoting it to most-recently-used.
+func (c *blockCache) get(number uint64) (*common.Block, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[number]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.ll.MoveToFront(elem)
+	return elem.Value.(*blockCacheEntry).block, true
+}
+
+// put adds block under number, evicting the least-recently-used entry if the cache is at capacity.
+func (c *blockCache) put(number uint64, block *common.Block) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[number]; ok {
+		elem.Value.(*blockCacheEntry).block = block
+		c.ll.MoveToFront(elem)
+		return
+	}
+	elem := c.ll.PushFront(&blockCacheEntry{number: number, block: block})
+	c.items[number] = elem
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*blockCacheEntry).number)
+		}
+	}
+}
+
+// stats returns the cumulative hit and miss counts, for operators tuning the cache size.
+func (c *blockCache) stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
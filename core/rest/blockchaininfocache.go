@@ -0,0 +1,94 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// defaultBlockchainInfoCacheTTL is used when rest.blockchainInfoCacheTTL is unset in configuration.
+const defaultBlockchainInfoCacheTTL = 500 * time.Millisecond
+
+// blockchainInfoCacheTTL returns the configured lifetime of a cached GetBlockchainInfo result,
+// reading rest.blockchainInfoCacheTTL and falling back to defaultBlockchainInfoCacheTTL. A TTL of
+// zero disables caching entirely.
+func blockchainInfoCacheTTL() time.Duration {
+	if viper.IsSet("rest.blockchainInfoCacheTTL") {
+		return viper.GetDuration("rest.blockchainInfoCacheTTL")
+	}
+	return defaultBlockchainInfoCacheTTL
+}
+
+// blockchainInfoCache holds the most recently fetched *pb.BlockchainInfo for up to a configurable
+// TTL, so that a burst of concurrent pollers collapses into a single upstream fetch. While a fetch
+// is in flight, callers that arrive for a stale or absent entry wait on it and share its result
+// (a singleflight-style guard) instead of each starting their own fetch; the first caller's context
+// governs that shared fetch.
+type blockchainInfoCache struct {
+	mu        sync.Mutex
+	info      *pb.BlockchainInfo
+	fetchedAt time.Time
+	err       error
+	inflight  chan struct{}
+}
+
+// get returns the cached *pb.BlockchainInfo if it is younger than the configured TTL, otherwise
+// calls fetch (at most once per group of concurrently waiting callers) and caches its result.
+// Caching is bypassed entirely when the configured TTL is zero.
+func (c *blockchainInfoCache) get(fetch func() (*pb.BlockchainInfo, error)) (*pb.BlockchainInfo, error) {
+	ttl := blockchainInfoCacheTTL()
+	if ttl <= 0 {
+		return fetch()
+	}
+
+	c.mu.Lock()
+	if c.info != nil && time.Since(c.fetchedAt) < ttl {
+		info := c.info
+		c.mu.Unlock()
+		return info, nil
+	}
+	if c.inflight != nil {
+		ch := c.inflight
+		c.mu.Unlock()
+		<-ch
+		c.mu.Lock()
+		info, err := c.info, c.err
+		c.mu.Unlock()
+		return info, err
+	}
+	ch := make(chan struct{})
+	c.inflight = ch
+	c.mu.Unlock()
+
+	info, err := fetch()
+
+	c.mu.Lock()
+	c.info, c.err = info, err
+	if err == nil {
+		c.fetchedAt = time.Now()
+	}
+	c.inflight = nil
+	c.mu.Unlock()
+	close(ch)
+
+	return info, err
+}
@@ -0,0 +1,100 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"google.golang.org/grpc"
+
+	context "golang.org/x/net/context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// openchainClient is the subset of the (not yet formally defined) validator-side Openchain service
+// that ServerOpenchain proxies to. It is hand-rolled against grpc.Invoke, in the same style
+// protoc-gen-go would produce, rather than generated from a .proto file, since no Openchain service
+// definition exists in this tree yet.
+type openchainClient interface {
+	GetBlockchainInfo(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*pb.BlockchainInfo, error)
+	GetBlockByNumber(ctx context.Context, in *BlockNumber, opts ...grpc.CallOption) (*common.Block, error)
+	GetBlockCount(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*BlockCount, error)
+	GetTransactionByID(ctx context.Context, in *TransactionQuery, opts ...grpc.CallOption) (*pb.Transaction, error)
+	GetBlocksByRange(ctx context.Context, in *BlockRange, opts ...grpc.CallOption) (*BlockList, error)
+	GetStateMultipleKeys(ctx context.Context, in *StateQuery, opts ...grpc.CallOption) (*StateValues, error)
+}
+
+type openchainClientImpl struct {
+	cc *grpc.ClientConn
+}
+
+// newOpenchainClient wraps conn in an openchainClient, propagating the caller's ctx (and thus its
+// cancellation and deadline) into the underlying grpc.Invoke call instead of substituting
+// context.Background(), so a client that gives up does not leave the proxying goroutine waiting on
+// the validator.
+func newOpenchainClient(conn *grpc.ClientConn) openchainClient {
+	return &openchainClientImpl{cc: conn}
+}
+
+func (c *openchainClientImpl) GetBlockchainInfo(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*pb.BlockchainInfo, error) {
+	out := new(pb.BlockchainInfo)
+	if err := grpc.Invoke(ctx, "/protos.Openchain/GetBlockchainInfo", in, out, c.cc, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openchainClientImpl) GetBlockByNumber(ctx context.Context, in *BlockNumber, opts ...grpc.CallOption) (*common.Block, error) {
+	out := new(common.Block)
+	if err := grpc.Invoke(ctx, "/protos.Openchain/GetBlockByNumber", in, out, c.cc, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openchainClientImpl) GetBlockCount(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*BlockCount, error) {
+	out := new(BlockCount)
+	if err := grpc.Invoke(ctx, "/protos.Openchain/GetBlockCount", in, out, c.cc, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openchainClientImpl) GetTransactionByID(ctx context.Context, in *TransactionQuery, opts ...grpc.CallOption) (*pb.Transaction, error) {
+	out := new(pb.Transaction)
+	if err := grpc.Invoke(ctx, "/protos.Openchain/GetTransactionByID", in, out, c.cc, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openchainClientImpl) GetBlocksByRange(ctx context.Context, in *BlockRange, opts ...grpc.CallOption) (*BlockList, error) {
+	out := new(BlockList)
+	if err := grpc.Invoke(ctx, "/protos.Openchain/GetBlocksByRange", in, out, c.cc, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openchainClientImpl) GetStateMultipleKeys(ctx context.Context, in *StateQuery, opts ...grpc.CallOption) (*StateValues, error) {
+	out := new(StateValues)
+	if err := grpc.Invoke(ctx, "/protos.Openchain/GetStateMultipleKeys", in, out, c.cc, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"sync"
+	"time"
+
+	context "golang.org/x/net/context"
+
+	"google.golang.org/grpc"
+)
+
+// connectionPool caches a *grpc.ClientConn per validator address, so that repeated calls proxied to
+// the same validator reuse one underlying connection instead of paying dial/teardown cost on every
+// call. It is safe for concurrent use.
+type connectionPool struct {
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// newConnectionPool constructs an empty connectionPool.
+func newConnectionPool() *connectionPool {
+	return &connectionPool{conns: map[string]*grpc.ClientConn{}}
+}
+
+// getConn returns a cached connection to addr, lazily dialing and caching a new one if none is
+// cached yet. The dial itself runs without holding the pool's lock, so one slow or stalled dial
+// never blocks getConn/evict calls for unrelated addresses.
+//
+// dialTimeout bounds how long the underlying grpc.Dial may itself block trying to connect -
+// grpc.WithTimeout has no effect without grpc.WithBlock, so getConn passes both, meaning a fresh
+// dial against an unreachable validator fails after dialTimeout instead of returning a connection
+// that only fails on first use. ctx separately bounds how long the caller is willing to wait for
+// getConn to return at all, so that a cancelled or already-expired caller context is honored even
+// while a dial is in flight; a dial still running when ctx is done keeps running in the background
+// and, if it eventually succeeds, is cached for the next caller rather than wasted.
+//
+// transportCreds carries the grpc.DialOption selecting TLS or insecure transport (see
+// ServerOpenchain.dialOptions), and keepaliveOpt is the grpc.DialOption that keeps the resulting
+// connection alive with a TCP-level keepalive (see keepaliveDialOption); none of the three has any
+// effect on a connection already cached.
+func (p *connectionPool) getConn(ctx context.Context, addr string, dialTimeout time.Duration, transportCreds, keepaliveOpt grpc.DialOption) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	conn, ok := p.conns[addr]
+	p.mu.Unlock()
+	if ok {
+		return conn, nil
+	}
+
+	type dialResult struct {
+		conn *grpc.ClientConn
+		err  error
+	}
+	dialDone := make(chan dialResult, 1)
+	go func() {
+		conn, err := grpc.Dial(addr, transportCreds, keepaliveOpt, grpc.WithBlock(), grpc.WithTimeout(dialTimeout))
+		if err == nil {
+			p.mu.Lock()
+			if existing, ok := p.conns[addr]; ok {
+				// another caller raced us to dial addr first; keep its connection and discard ours.
+				conn.Close()
+				conn = existing
+			} else {
+				p.conns[addr] = conn
+			}
+			p.mu.Unlock()
+		}
+		dialDone <- dialResult{conn, err}
+	}()
+
+	select {
+	case r := <-dialDone:
+		return r.conn, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// evict closes and discards the cached connection to addr, if any, so that the next getConn call
+// dials a fresh one. Callers should evict a connection after an RPC on it fails with a transient
+// error (e.g. codes.Unavailable); this grpc client library version does not expose a queryable
+// connectivity state, so a failed RPC is the only signal available for detecting a dead connection.
+func (p *connectionPool) evict(addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if conn, ok := p.conns[addr]; ok {
+		conn.Close()
+		delete(p.conns, addr)
+	}
+}
+
+// Close closes every connection currently cached by the pool, for use on server shutdown.
+func (p *connectionPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for addr, conn := range p.conns {
+		conn.Close()
+		delete(p.conns, addr)
+	}
+}
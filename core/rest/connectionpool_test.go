@@ -0,0 +1,109 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	context "golang.org/x/net/context"
+
+	"google.golang.org/grpc"
+)
+
+// newTestGRPCServer starts a bare grpc.Server on a loopback port and returns its address, so that
+// getConn - which blocks until a connection actually comes up - has a real peer to connect to. The
+// caller must Stop the returned server.
+func newTestGRPCServer(t *testing.T) (addr string, server *grpc.Server) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	server = grpc.NewServer()
+	go server.Serve(lis)
+	return lis.Addr().String(), server
+}
+
+func TestConnectionPoolCachesConnections(t *testing.T) {
+	addr, server := newTestGRPCServer(t)
+	defer server.Stop()
+
+	pool := newConnectionPool()
+	defer pool.Close()
+
+	conn1, err := pool.getConn(context.Background(), addr, time.Second, grpc.WithInsecure(), keepaliveDialOption())
+	if err != nil {
+		t.Fatalf("unexpected error dialing %s: %s", addr, err)
+	}
+	conn2, err := pool.getConn(context.Background(), addr, time.Second, grpc.WithInsecure(), keepaliveDialOption())
+	if err != nil {
+		t.Fatalf("unexpected error dialing %s: %s", addr, err)
+	}
+	if conn1 != conn2 {
+		t.Fatalf("expected getConn to return the same cached connection on a second call")
+	}
+}
+
+func TestConnectionPoolEvict(t *testing.T) {
+	addr, server := newTestGRPCServer(t)
+	defer server.Stop()
+
+	pool := newConnectionPool()
+	defer pool.Close()
+
+	conn1, err := pool.getConn(context.Background(), addr, time.Second, grpc.WithInsecure(), keepaliveDialOption())
+	if err != nil {
+		t.Fatalf("unexpected error dialing %s: %s", addr, err)
+	}
+
+	pool.evict(addr)
+
+	conn2, err := pool.getConn(context.Background(), addr, time.Second, grpc.WithInsecure(), keepaliveDialOption())
+	if err != nil {
+		t.Fatalf("unexpected error re-dialing %s: %s", addr, err)
+	}
+	if conn1 == conn2 {
+		t.Fatalf("expected evict to force a fresh connection on the next getConn call")
+	}
+}
+
+// TestConnectionPoolGetConnBoundsDialLatencyToUnreachableValidator guards against a dialTimeout that
+// looks configured but does nothing: without grpc.WithBlock, grpc.WithTimeout has no effect (the
+// vendored grpc.WithTimeout doc comment says as much) and Dial returns a connection immediately no
+// matter whether the peer is reachable, so a call against an unreachable validator never actually
+// gets bounded by dialTimeout. 192.0.2.1 is from the TEST-NET-1 block (RFC 5737), reserved for
+// exactly this kind of test and guaranteed to refuse the connection rather than route anywhere real.
+func TestConnectionPoolGetConnBoundsDialLatencyToUnreachableValidator(t *testing.T) {
+	pool := newConnectionPool()
+	defer pool.Close()
+
+	const dialTimeout = 200 * time.Millisecond
+	const unreachableAddr = "192.0.2.1:7051"
+
+	start := time.Now()
+	_, err := pool.getConn(context.Background(), unreachableAddr, dialTimeout, grpc.WithInsecure(), keepaliveDialOption())
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatalf("expected an error dialing an unreachable address")
+	}
+	if elapsed > 2*dialTimeout {
+		t.Fatalf("expected getConn to bound dial latency to roughly %s, took %s", dialTimeout, elapsed)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	context "golang.org/x/net/context"
+
+	"github.com/spf13/viper"
+
+	"github.com/hyperledger/fabric/core/util"
+)
+
+// defaultCursorTTL is used when rest.cursorTTL is unset in configuration.
+const defaultCursorTTL = 5 * time.Minute
+
+// defaultCursorBatchSize is used by NextBlocks when the caller does not request a specific batch
+// size.
+const defaultCursorBatchSize = 10
+
+// errCursorNotFound is returned by NextBlocks and CloseCursor for a cursor id that is unknown,
+// either because it was never issued or because it has since expired or been exhausted/closed.
+var errCursorNotFound = errors.New("rest: unknown or expired cursor")
+
+// blockCursorState tracks one open OpenBlockCursor call's progress through its requested range.
+type blockCursorState struct {
+	end       uint64
+	next      uint64
+	expiresAt time.Time
+}
+
+// cursorTTL returns the configured idle lifetime of an open cursor, reading rest.cursorTTL and
+// falling back to defaultCursorTTL.
+func cursorTTL() time.Duration {
+	if viper.IsSet("rest.cursorTTL") {
+		return viper.GetDuration("rest.cursorTTL")
+	}
+	return defaultCursorTTL
+}
+
+// OpenBlockCursor validates [start, end] (the same bounds GetBlocksByRange enforces) and returns a
+// cursor id that NextBlocks can use to page through the range on demand, and CloseCursor can use to
+// release early. An open cursor expires, and becomes unusable, after cursorTTL of inactivity.
+func (s *ServerOpenchain) OpenBlockCursor(ctx context.Context, start, end uint64) (cursorID string, err error) {
+	defer func(startTime time.Time) { metrics.observeRequest("OpenBlockCursor", time.Since(startTime), err) }(time.Now())
+	if end < start {
+		return "", toStatusError(fmt.Errorf("%w: end block %d must not precede start block %d", ErrInvalidArgument, end, start))
+	}
+	if span, max := end-start+1, maxBlockRangeSpan(); span > max {
+		return "", toStatusError(fmt.Errorf("%w: requested range of %d blocks exceeds the maximum of %d", ErrInvalidArgument, span, max))
+	}
+
+	s.cursorsMu.Lock()
+	defer s.cursorsMu.Unlock()
+	id := util.GenerateUUID()
+	s.cursors[id] = &blockCursorState{end: end, next: start, expiresAt: time.Now().Add(cursorTTL())}
+	return id, nil
+}
+
+// NextBlocks returns the next batch (at most batchSize blocks, falling back to
+// defaultCursorBatchSize when batchSize <= 0) from cursorID's range, advancing the cursor and
+// resetting its expiry. Once the cursor's range is exhausted the cursor is removed and the final,
+// possibly short or empty, batch is returned. Returns errCursorNotFound for an unknown or expired
+// cursor id.
+func (s *ServerOpenchain) NextBlocks(ctx context.Context, cursorID string, batchSize int) (result *BlockList, err error) {
+	defer func(start time.Time) { metrics.observeRequest("NextBlocks", time.Since(start), err) }(time.Now())
+	if batchSize <= 0 {
+		batchSize = defaultCursorBatchSize
+	}
+
+	s.cursorsMu.Lock()
+	state, ok := s.cursors[cursorID]
+	if !ok || time.Now().After(state.expiresAt) {
+		delete(s.cursors, cursorID)
+		s.cursorsMu.Unlock()
+		return nil, toStatusError(errCursorNotFound)
+	}
+	batchStart := state.next
+	batchEnd := batchStart + uint64(batchSize) - 1
+	if batchEnd > state.end {
+		batchEnd = state.end
+	}
+	state.next = batchEnd + 1
+	state.expiresAt = time.Now().Add(cursorTTL())
+	exhausted := state.next > state.end
+	if exhausted {
+		delete(s.cursors, cursorID)
+	}
+	s.cursorsMu.Unlock()
+
+	return s.GetBlocksByRange(ctx, batchStart, batchEnd)
+}
+
+// CloseCursor releases cursorID before its TTL would otherwise expire it. Closing an unknown or
+// already-closed cursor is not an error.
+func (s *ServerOpenchain) CloseCursor(cursorID string) {
+	start := time.Now()
+	defer func() { metrics.observeRequest("CloseCursor", time.Since(start), nil) }()
+	s.cursorsMu.Lock()
+	delete(s.cursors, cursorID)
+	s.cursorsMu.Unlock()
+}
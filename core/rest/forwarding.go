@@ -0,0 +1,119 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	context "golang.org/x/net/context"
+)
+
+// ForwardingStats reports a validator's outstanding forwarded-call load: how many calls are
+// currently executing against it, how many are queued waiting for a free slot, and how many of its
+// recent calls ended in error.
+type ForwardingStats struct {
+	InFlight uint64
+	Queued   uint64
+	Errors   uint64
+}
+
+// ForwardingTracker records in-flight, queued, and error counts for calls forwarded to validator
+// peers, keyed by validator address, so operators can see forwarding load per validator. It is safe
+// for concurrent use.
+type ForwardingTracker struct {
+	mu    sync.Mutex
+	stats map[string]*ForwardingStats
+}
+
+// NewForwardingTracker constructs an empty ForwardingTracker.
+func NewForwardingTracker() *ForwardingTracker {
+	return &ForwardingTracker{stats: map[string]*ForwardingStats{}}
+}
+
+// Queued records that a call to addr has been queued, awaiting a free slot to execute.
+func (f *ForwardingTracker) Queued(addr string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entry(addr).Queued++
+}
+
+// Begin records that a queued call to addr has started executing.
+func (f *ForwardingTracker) Begin(addr string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry := f.entry(addr)
+	if entry.Queued > 0 {
+		entry.Queued--
+	}
+	entry.InFlight++
+}
+
+// End records that an in-flight call to addr has completed, incrementing its error count if the
+// call failed.
+func (f *ForwardingTracker) End(addr string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry := f.entry(addr)
+	if entry.InFlight > 0 {
+		entry.InFlight--
+	}
+	if err != nil {
+		entry.Errors++
+	}
+}
+
+// Stats returns a snapshot of the current stats for every validator address seen so far.
+func (f *ForwardingTracker) Stats() map[string]*ForwardingStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	snapshot := make(map[string]*ForwardingStats, len(f.stats))
+	for addr, s := range f.stats {
+		statsCopy := *s
+		snapshot[addr] = &statsCopy
+	}
+	return snapshot
+}
+
+// GetForwardingStats reports the current in-flight, queued, and error counts for every validator
+// address this server has forwarded a call to, sorted by address for a deterministic result.
+func (s *ServerOpenchain) GetForwardingStats(ctx context.Context) (result *ForwardingStatsResult, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetForwardingStats", time.Since(start), err) }(time.Now())
+	stats := s.forwarding.Stats()
+	validators := make([]*ValidatorForwardingStats, 0, len(stats))
+	for addr, st := range stats {
+		validators = append(validators, &ValidatorForwardingStats{
+			Address:  addr,
+			InFlight: st.InFlight,
+			Queued:   st.Queued,
+			Errors:   st.Errors,
+		})
+	}
+	sort.Slice(validators, func(i, j int) bool { return validators[i].Address < validators[j].Address })
+	return &ForwardingStatsResult{Validators: validators}, nil
+}
+
+// entry returns the stats entry for addr, creating it if necessary. Callers must hold f.mu.
+func (f *ForwardingTracker) entry(addr string) *ForwardingStats {
+	entry, ok := f.stats[addr]
+	if !ok {
+		entry = &ForwardingStats{}
+		f.stats[addr] = entry
+	}
+	return entry
+}
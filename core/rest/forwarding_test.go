@@ -0,0 +1,146 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	context "golang.org/x/net/context"
+
+	"github.com/spf13/viper"
+)
+
+func TestForwardingTrackerConcurrentCalls(t *testing.T) {
+	tracker := NewForwardingTracker()
+	const addr = "validator1:7051"
+	const numCalls = 10
+
+	var wg sync.WaitGroup
+	wg.Add(numCalls)
+	for i := 0; i < numCalls; i++ {
+		go func(i int) {
+			defer wg.Done()
+			tracker.Queued(addr)
+			tracker.Begin(addr)
+			var err error
+			if i%2 == 0 {
+				err = errors.New("forwarded call failed")
+			}
+			tracker.End(addr, err)
+		}(i)
+	}
+	wg.Wait()
+
+	stats := tracker.Stats()
+	entry, ok := stats[addr]
+	if !ok {
+		t.Fatalf("expected stats for %s", addr)
+	}
+	if entry.InFlight != 0 {
+		t.Fatalf("expected InFlight to settle at 0, got %d", entry.InFlight)
+	}
+	if entry.Queued != 0 {
+		t.Fatalf("expected Queued to settle at 0, got %d", entry.Queued)
+	}
+	if entry.Errors != numCalls/2 {
+		t.Fatalf("expected Errors = %d, got %d", numCalls/2, entry.Errors)
+	}
+}
+
+func TestForwardingTrackerInFlightAndQueuedDuringCalls(t *testing.T) {
+	tracker := NewForwardingTracker()
+	const addr = "validator2:7051"
+
+	tracker.Queued(addr)
+	tracker.Queued(addr)
+	if stats := tracker.Stats()[addr]; stats.Queued != 2 {
+		t.Fatalf("expected Queued = 2, got %d", stats.Queued)
+	}
+
+	tracker.Begin(addr)
+	stats := tracker.Stats()[addr]
+	if stats.Queued != 1 {
+		t.Fatalf("expected Queued = 1 after Begin, got %d", stats.Queued)
+	}
+	if stats.InFlight != 1 {
+		t.Fatalf("expected InFlight = 1 after Begin, got %d", stats.InFlight)
+	}
+
+	tracker.End(addr, nil)
+	stats = tracker.Stats()[addr]
+	if stats.InFlight != 0 {
+		t.Fatalf("expected InFlight = 0 after End, got %d", stats.InFlight)
+	}
+	if stats.Errors != 0 {
+		t.Fatalf("expected Errors = 0 for a successful call, got %d", stats.Errors)
+	}
+}
+
+func TestGetForwardingStatsReflectsConcurrentCalls(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	const addrA, addrB = "validator1:7051", "validator2:7051"
+	const numCalls = 10
+
+	var wg sync.WaitGroup
+	wg.Add(numCalls)
+	for i := 0; i < numCalls; i++ {
+		go func(i int) {
+			defer wg.Done()
+			addr := addrA
+			if i%2 == 0 {
+				addr = addrB
+			}
+			server.forwarding.Queued(addr)
+			server.forwarding.Begin(addr)
+			var callErr error
+			if i%3 == 0 {
+				callErr = errors.New("forwarded call failed")
+			}
+			server.forwarding.End(addr, callErr)
+		}(i)
+	}
+	wg.Wait()
+
+	result, err := server.GetForwardingStats(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Validators) != 2 {
+		t.Fatalf("expected stats for 2 validators, got %d", len(result.Validators))
+	}
+	if result.Validators[0].Address != addrA || result.Validators[1].Address != addrB {
+		t.Fatalf("expected validators sorted by address, got %+v", result.Validators)
+	}
+	for _, v := range result.Validators {
+		if v.InFlight != 0 {
+			t.Fatalf("expected InFlight to settle at 0 for %s, got %d", v.Address, v.InFlight)
+		}
+		if v.Queued != 0 {
+			t.Fatalf("expected Queued to settle at 0 for %s, got %d", v.Address, v.Queued)
+		}
+	}
+}
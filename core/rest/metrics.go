@@ -0,0 +1,161 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// requestMetricKey identifies one (method, outcome) bucket of request counts and latency.
+type requestMetricKey struct {
+	method  string
+	outcome string
+}
+
+// requestMetricValue accumulates count and total latency for a requestMetricKey, from which the
+// mean latency exposed in the exposition format is derived.
+type requestMetricValue struct {
+	count               uint64
+	totalLatencySeconds float64
+}
+
+// metricsRegistry accumulates request counts and latencies, validator-dial latency, and
+// failover-attempt counts for every ServerOpenchain method, in the absence of a vendored
+// Prometheus client library (see render, which hand-formats this state in the standard Prometheus
+// text exposition format rather than depending on one). A single package-level instance (metrics)
+// is shared by every ServerOpenchain, so constructing more than one server only adds more
+// observations to the same counters instead of attempting any kind of registration that could
+// panic on a second call.
+type metricsRegistry struct {
+	mu sync.Mutex
+
+	requests map[requestMetricKey]*requestMetricValue
+
+	dialCount               uint64
+	dialTotalLatencySeconds float64
+
+	failoverAttempts uint64
+}
+
+// metrics is the single registry instance every ServerOpenchain records observations into.
+var metrics = &metricsRegistry{requests: map[requestMetricKey]*requestMetricValue{}}
+
+// outcomeOf classifies err for metrics purposes into one of "ok", "notfound", or "error", the
+// outcome labels this package reports.
+func outcomeOf(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case grpc.Code(err) == codes.NotFound:
+		return "notfound"
+	default:
+		return "error"
+	}
+}
+
+// observeRequest records one completed call to method, classifying it into an outcome bucket via
+// outcomeOf(err) and adding elapsed to that bucket's latency total.
+func (r *metricsRegistry) observeRequest(method string, elapsed time.Duration, err error) {
+	key := requestMetricKey{method: method, outcome: outcomeOf(err)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.requests[key]
+	if !ok {
+		v = &requestMetricValue{}
+		r.requests[key] = v
+	}
+	v.count++
+	v.totalLatencySeconds += elapsed.Seconds()
+}
+
+// observeDial records one attempt to dial a validator, successful or not, so validator-dial
+// latency can be tracked separately from overall request latency as the main source of tail
+// latency.
+func (r *metricsRegistry) observeDial(elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dialCount++
+	r.dialTotalLatencySeconds += elapsed.Seconds()
+}
+
+// recordFailoverAttempt records that forwardToValidator gave up on one validator address and
+// advanced to the next, so failover frequency can be tracked separately from ordinary request
+// outcomes.
+func (r *metricsRegistry) recordFailoverAttempt() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failoverAttempts++
+}
+
+// render returns the registry's current state in the standard Prometheus text exposition format,
+// for an existing /metrics handler to write out as its response body.
+func (r *metricsRegistry) render() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP rest_requests_total Total number of ServerOpenchain requests by method and outcome.\n")
+	b.WriteString("# TYPE rest_requests_total counter\n")
+	b.WriteString("# HELP rest_request_latency_seconds_sum Cumulative request latency in seconds by method and outcome.\n")
+	b.WriteString("# TYPE rest_request_latency_seconds_sum counter\n")
+
+	keys := make([]requestMetricKey, 0, len(r.requests))
+	for key := range r.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].outcome < keys[j].outcome
+	})
+	for _, key := range keys {
+		v := r.requests[key]
+		fmt.Fprintf(&b, "rest_requests_total{method=%q,outcome=%q} %d\n", key.method, key.outcome, v.count)
+		fmt.Fprintf(&b, "rest_request_latency_seconds_sum{method=%q,outcome=%q} %f\n", key.method, key.outcome, v.totalLatencySeconds)
+	}
+
+	b.WriteString("# HELP rest_validator_dial_latency_seconds_sum Cumulative time spent dialing validators.\n")
+	b.WriteString("# TYPE rest_validator_dial_latency_seconds_sum counter\n")
+	fmt.Fprintf(&b, "rest_validator_dial_latency_seconds_sum %f\n", r.dialTotalLatencySeconds)
+	b.WriteString("# HELP rest_validator_dial_total Total number of validator dial attempts.\n")
+	b.WriteString("# TYPE rest_validator_dial_total counter\n")
+	fmt.Fprintf(&b, "rest_validator_dial_total %d\n", r.dialCount)
+
+	b.WriteString("# HELP rest_validator_failover_attempts_total Total number of times a proxied call failed over from one validator to the next.\n")
+	b.WriteString("# TYPE rest_validator_failover_attempts_total counter\n")
+	fmt.Fprintf(&b, "rest_validator_failover_attempts_total %d\n", r.failoverAttempts)
+
+	return b.String()
+}
+
+// MetricsHandler is an http.HandlerFunc rendering the package's accumulated metrics in the
+// standard Prometheus text exposition format. This package runs no HTTP server of its own, so a
+// caller mounts this at whatever path (conventionally /metrics) its own mux scrapes.
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	io.WriteString(w, metrics.render())
+}
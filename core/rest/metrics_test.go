@@ -0,0 +1,72 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsRegistryObserveRequestClassifiesOutcome(t *testing.T) {
+	r := &metricsRegistry{requests: map[requestMetricKey]*requestMetricValue{}}
+	r.observeRequest("TestMethod", 10*time.Millisecond, nil)
+	r.observeRequest("TestMethod", 20*time.Millisecond, toStatusError(ErrNotFound))
+	r.observeRequest("TestMethod", 30*time.Millisecond, errors.New("boom"))
+
+	rendered := r.render()
+	for _, want := range []string{
+		`rest_requests_total{method="TestMethod",outcome="ok"} 1`,
+		`rest_requests_total{method="TestMethod",outcome="notfound"} 1`,
+		`rest_requests_total{method="TestMethod",outcome="error"} 1`,
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Fatalf("expected rendered metrics to contain %q, got:\n%s", want, rendered)
+		}
+	}
+}
+
+func TestMetricsRegistryTracksDialLatencyAndFailoverAttempts(t *testing.T) {
+	r := &metricsRegistry{requests: map[requestMetricKey]*requestMetricValue{}}
+	r.observeDial(5 * time.Millisecond)
+	r.observeDial(15 * time.Millisecond)
+	r.recordFailoverAttempt()
+
+	if r.dialCount != 2 {
+		t.Fatalf("expected dialCount = 2, got %d", r.dialCount)
+	}
+	if r.failoverAttempts != 1 {
+		t.Fatalf("expected failoverAttempts = 1, got %d", r.failoverAttempts)
+	}
+
+	rendered := r.render()
+	if !strings.Contains(rendered, "rest_validator_dial_total 2") {
+		t.Fatalf("expected rendered metrics to report 2 dials, got:\n%s", rendered)
+	}
+	if !strings.Contains(rendered, "rest_validator_failover_attempts_total 1") {
+		t.Fatalf("expected rendered metrics to report 1 failover attempt, got:\n%s", rendered)
+	}
+}
+
+func TestNewOpenchainServerConstructionDoesNotPanicWhenCalledMultipleTimes(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		if _, err := NewOpenchainServer(); err != nil {
+			t.Fatalf("unexpected error constructing server #%d: %s", i, err)
+		}
+	}
+}
@@ -0,0 +1,1106 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	context "golang.org/x/net/context"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// errNoValidators is returned by the proxying methods when no validator address is configured to
+// forward the call to.
+var errNoValidators = errors.New("rest: no validator addresses configured")
+
+// ErrEmptyBlockchain is returned by GetBlockByNumber and GetBlockCount when the chain has not yet
+// committed any blocks, mirroring kvledger.ErrEmptyBlockchain one layer up so the same condition
+// stays distinguishable via errors.Is all the way out to an HTTP/gRPC boundary, instead of being
+// indistinguishable from a genuine retrieval failure (ErrBlockRetrieval).
+var ErrEmptyBlockchain = errors.New("rest: blockchain has no blocks")
+
+// ErrBlockRetrieval wraps a forwardToValidator failure encountered while retrieving blockchain data,
+// so callers can use errors.Is to tell a retrieval failure apart from a validator-configuration
+// problem (errNoValidators) or an empty chain (ErrEmptyBlockchain) without parsing message text.
+var ErrBlockRetrieval = errors.New("rest: failed to retrieve block data")
+
+// wrapRetrievalErr wraps a non-nil forwardToValidator error as ErrBlockRetrieval, preserving the
+// underlying message. errNoValidators is left unwrapped since it is already its own distinct,
+// comparable sentinel describing a different error class (missing configuration, not a failed
+// retrieval attempt).
+func wrapRetrievalErr(err error) error {
+	if err == nil || err == errNoValidators {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrBlockRetrieval, err)
+}
+
+// getValidatorAddresses returns every configured validator address (rest.validatorAddresses) that
+// is not currently in cooldown from a recent transient failure (or every configured address, if all
+// of them are in cooldown), ordered according to
+// rest.validatorSelection:
+//   - "random" (the default): shuffled, so repeated calls spread load across the set and so that
+//     callers iterating the slice for failover don't all retry in the same fixed order.
+//   - "round-robin": rotated one position further than the previous call, via s.roundRobin, so
+//     consecutive calls are spread evenly regardless of request rate.
+//
+// Returns errNoValidators if none are configured.
+func (s *ServerOpenchain) getValidatorAddresses() ([]string, error) {
+	configured := viper.GetStringSlice("rest.validatorAddresses")
+	if len(configured) == 0 {
+		return nil, errNoValidators
+	}
+	addrs := make([]string, len(configured))
+	copy(addrs, configured)
+	addrs = s.cooldowns.filter(addrs)
+
+	if viper.GetString("rest.validatorSelection") == "round-robin" {
+		return s.roundRobin.order(addrs), nil
+	}
+	for i := len(addrs) - 1; i > 0; i-- {
+		j := rand.Intn(i + 1)
+		addrs[i], addrs[j] = addrs[j], addrs[i]
+	}
+	return addrs, nil
+}
+
+// dial returns a client for addr, reusing a pooled connection where possible. The time spent
+// resolving a connection (pooled or freshly dialed) is recorded separately from overall request
+// latency, since dialing a cold validator is one of the main sources of tail latency. ctx is
+// propagated into the pooled dial so that a cancelled or expired caller context aborts a slow dial
+// promptly instead of only bounding the RPC made once dialing completes.
+func (s *ServerOpenchain) dial(ctx context.Context, addr string) (openchainClient, error) {
+	start := time.Now()
+	defer func() { metrics.observeDial(time.Since(start)) }()
+	transportCreds, err := s.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := s.pool.getConn(ctx, addr, s.validatorDialTimeout, transportCreds, keepaliveDialOption())
+	if err != nil {
+		return nil, err
+	}
+	return newOpenchainClient(conn), nil
+}
+
+// isTransientDialError reports whether err looks like a transient failure of the validator
+// currently being tried, worth failing over to the next validator, as opposed to a permanent error
+// (e.g. NotFound, InvalidArgument) that trying another validator would not fix.
+func isTransientDialError(err error) bool {
+	switch grpc.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// defaultRetryMaxAttempts is used by callWithRetry when rest.retryMaxAttempts is unset.
+const defaultRetryMaxAttempts = 3
+
+// defaultRetryBaseDelay is used by callWithRetry when rest.retryBaseDelay is unset.
+const defaultRetryBaseDelay = 50 * time.Millisecond
+
+// retryMaxAttempts returns the configured number of times callWithRetry will attempt a call before
+// giving up on the current validator, reading rest.retryMaxAttempts and falling back to
+// defaultRetryMaxAttempts. A configured value below 1 is treated as 1, so a call is always attempted
+// at least once.
+func retryMaxAttempts() int {
+	if viper.IsSet("rest.retryMaxAttempts") {
+		if attempts := viper.GetInt("rest.retryMaxAttempts"); attempts >= 1 {
+			return attempts
+		}
+		return 1
+	}
+	return defaultRetryMaxAttempts
+}
+
+// retryBaseDelay returns the configured base delay callWithRetry's exponential backoff grows from,
+// reading rest.retryBaseDelay and falling back to defaultRetryBaseDelay.
+func retryBaseDelay() time.Duration {
+	if viper.IsSet("rest.retryBaseDelay") {
+		return viper.GetDuration("rest.retryBaseDelay")
+	}
+	return defaultRetryBaseDelay
+}
+
+// backoffDelay returns the delay to sleep before retry attempt number attempt (1-indexed, the delay
+// before the 2nd try), doubling base each attempt and then applying full jitter (a uniformly random
+// duration between 0 and the doubled value) so that callers retrying in lockstep after a shared
+// validator outage don't all retry in lockstep again.
+func backoffDelay(attempt int, base time.Duration) time.Duration {
+	exp := base * time.Duration(uint64(1)<<uint(attempt-1))
+	if exp <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(exp)))
+}
+
+// callWithRetry invokes call up to retryMaxAttempts times (configurable via rest.retryMaxAttempts),
+// sleeping an exponentially growing, jittered delay (configurable via rest.retryBaseDelay) between
+// attempts, but only for a transient failure (isTransientDialError) -- a permanent failure such as
+// NotFound or InvalidArgument is returned to the caller immediately without being retried. The sleep
+// between attempts is interrupted, and ctx.Err() returned, the moment ctx is done, so a retry loop
+// never outlives the caller's own deadline.
+func callWithRetry(ctx context.Context, call func() error) error {
+	maxAttempts := retryMaxAttempts()
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = call()
+		if err == nil || !isTransientDialError(err) || attempt == maxAttempts {
+			return err
+		}
+		select {
+		case <-time.After(backoffDelay(attempt, retryBaseDelay())):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// preferAddr returns addrs reordered so that preferred is tried first, if present. If preferred is
+// not in addrs (for example because it has dropped out of configuration or is currently in
+// cooldown), addrs is returned unchanged.
+func preferAddr(addrs []string, preferred string) []string {
+	idx := -1
+	for i, addr := range addrs {
+		if addr == preferred {
+			idx = i
+			break
+		}
+	}
+	if idx <= 0 {
+		return addrs
+	}
+	reordered := make([]string, 0, len(addrs))
+	reordered = append(reordered, preferred)
+	reordered = append(reordered, addrs[:idx]...)
+	reordered = append(reordered, addrs[idx+1:]...)
+	return reordered
+}
+
+// forwardToValidator calls call, retried with backoff via callWithRetry, against each configured
+// validator address in turn, evicting and failing over to the next address once callWithRetry gives
+// up on the current one, and returning once call succeeds or every address has been exhausted. On
+// exhaustion the returned error names every address attempted.
+//
+// The address most recently used successfully is tried first, for up to currentValidatorTTL, via
+// s.current: this keeps connection reuse high and avoids reselection churn between calls against a
+// stable validator set. A transient failure against the cached address invalidates it immediately,
+// same as any other address, so the next call reselects rather than retrying a known-bad target.
+func (s *ServerOpenchain) forwardToValidator(ctx context.Context, call func(addr string, client openchainClient) error) error {
+	addrs, err := s.getValidatorAddresses()
+	if err != nil {
+		return err
+	}
+	if cached, ok := s.current.get(); ok {
+		addrs = preferAddr(addrs, cached)
+	}
+	var lastErr error
+	for _, addr := range addrs {
+		s.forwarding.Queued(addr)
+		client, err := s.dial(ctx, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		s.forwarding.Begin(addr)
+		err = callWithRetry(ctx, func() error { return call(addr, client) })
+		s.forwarding.End(addr, err)
+		if err == nil {
+			s.current.set(addr)
+			return nil
+		}
+		lastErr = err
+		if !isTransientDialError(err) {
+			return err
+		}
+		metrics.recordFailoverAttempt()
+		s.current.invalidate(addr)
+		s.cooldowns.markFailed(addr)
+		s.pool.evict(addr)
+	}
+	return fmt.Errorf("rest: exhausted all %d validator(s) %v, last error: %s", len(addrs), addrs, lastErr)
+}
+
+// Ping reports whether this server considers itself able to serve requests, for use behind a load
+// balancer's readiness/liveness probe. This server always runs in proxy (non-validator) mode, so
+// unlike an embedded validator it has no local ledger to check against; instead it reports healthy
+// based on whether at least one configured validator address is currently available to proxy to,
+// which is the only thing this server itself can fail at. It does not dial or call any validator, so
+// it stays fast and side-effect free as required: ServerStatusDown when no validator addresses are
+// configured at all, ServerStatusDegraded when some are configured but all are currently in cooldown
+// from recent transient failures, and ServerStatusOK otherwise.
+func (s *ServerOpenchain) Ping(ctx context.Context) (status *ServerStatus, err error) {
+	defer func(start time.Time) { metrics.observeRequest("Ping", time.Since(start), err) }(time.Now())
+	configured := viper.GetStringSlice("rest.validatorAddresses")
+	if len(configured) == 0 {
+		return &ServerStatus{Status: ServerStatusDown, Message: "no validator addresses configured"}, nil
+	}
+	if s.cooldowns.allCooling(configured) {
+		return &ServerStatus{Status: ServerStatusDegraded, Message: "all configured validators are in cooldown"}, nil
+	}
+	return &ServerStatus{Status: ServerStatusOK, Message: "ok"}, nil
+}
+
+// GetBlockchainInfo proxies a GetBlockchainInfo call to a validator peer, propagating ctx into both
+// the dial and the downstream RPC so that a caller's cancellation or deadline is honored end to end
+// instead of being dropped at the proxy boundary, and failing over to another configured validator
+// on a transient error.
+func (s *ServerOpenchain) GetBlockchainInfo(ctx context.Context) (info *pb.BlockchainInfo, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetBlockchainInfo", time.Since(start), err) }(time.Now())
+	return s.infoCache.get(func() (*pb.BlockchainInfo, error) {
+		var info *pb.BlockchainInfo
+		err := s.forwardToValidator(ctx, func(addr string, client openchainClient) error {
+			result, err := client.GetBlockchainInfo(ctx, &empty.Empty{})
+			if err != nil {
+				return err
+			}
+			info = result
+			return nil
+		})
+		return info, toStatusError(wrapRetrievalErr(err))
+	})
+}
+
+// GetBlockByNumber proxies a GetBlockByNumber call to a validator peer, with the same context
+// propagation and validator failover behavior as GetBlockchainInfo. Blocks already known to be
+// strictly below the chain's current height are served from s.blockCache instead of re-dialing a
+// validator, since such a block can never change; a block fetched for the first time is cached only
+// once its own number is confirmed to be below the current height, so a block that might still be
+// reorged in edge cases is never cached. Returns ErrEmptyBlockchain if the chain has no committed
+// blocks yet; an error wrapping ErrInvalidArgument if number is beyond the chain's current height,
+// including an arbitrarily large number, since this check is a plain unsigned comparison against
+// info.Height and so cannot overflow regardless of how large number is; or an error wrapping
+// ErrBlockRetrieval if the validator call itself fails, so callers can tell the three apart with
+// errors.Is instead of parsing message text. In this ledger, block numbers run contiguously from 1
+// through the current height with no gaps, so an out-of-range number is always "too large" rather
+// than a genuine hole in an otherwise valid range; ErrNotFound is reserved for callers built on that
+// possibility (see GetBlockAtHeight) and is never returned here.
+func (s *ServerOpenchain) GetBlockByNumber(ctx context.Context, number uint64) (result *common.Block, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetBlockByNumber", time.Since(start), err) }(time.Now())
+	if cached, ok := s.blockCache.get(number); ok {
+		return cached, nil
+	}
+
+	info, err := s.GetBlockchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.Height == 0 {
+		return nil, toStatusError(ErrEmptyBlockchain)
+	}
+	if number > info.Height {
+		return nil, toStatusError(fmt.Errorf("%w: block %d exceeds the current chain height of %d", ErrInvalidArgument, number, info.Height))
+	}
+
+	var block *common.Block
+	err = s.forwardToValidator(ctx, func(addr string, client openchainClient) error {
+		result, err := client.GetBlockByNumber(ctx, &BlockNumber{Number: number})
+		if err != nil {
+			return err
+		}
+		block = result
+		if number < info.Height {
+			s.blockCache.put(number, block)
+		}
+		return nil
+	})
+	return block, toStatusError(wrapRetrievalErr(err))
+}
+
+// GetTransactionsByBlockNumber returns the raw transaction envelopes of the block at number,
+// without the per-transaction unpacking a caller would otherwise have to do after calling
+// GetBlockByNumber. includeCodePackage is accepted for forward compatibility with validators that
+// strip deploy CodePackages from the envelopes they return; this proxy does not itself decode or
+// alter the envelopes it receives, so the flag currently has no effect here. Returns ErrNotFound if
+// number is beyond the chain's current height, and otherwise shares GetBlockByNumber's caching,
+// context propagation, and validator failover behavior.
+func (s *ServerOpenchain) GetTransactionsByBlockNumber(ctx context.Context, number uint64, includeCodePackage bool) (result *TransactionList, err error) {
+	defer func(start time.Time) {
+		metrics.observeRequest("GetTransactionsByBlockNumber", time.Since(start), err)
+	}(time.Now())
+	info, err := s.GetBlockchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if number > info.Height {
+		return nil, toStatusError(ErrNotFound)
+	}
+
+	block, err := s.GetBlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return &TransactionList{Transactions: block.Data.Data}, nil
+}
+
+// GetBlockTxIDs returns just the transaction IDs of block number, in the order they appear in the
+// block, for a caller that wants to know what a block contains without paying for the full
+// transaction bodies. This proxy has no ledger call that reads only transaction IDs off a block
+// without fetching the rest of it, so unlike a ledger-side implementation this still retrieves the
+// full block via GetBlockByNumber -- though a block already present in s.blockCache still avoids a
+// validator round trip, same as GetTxIDBloomFilter. An envelope whose transaction ID cannot be
+// decoded is skipped, consistent with GetTxIDBloomFilter and GetAverageEndorsements. Returns
+// ErrNotFound for a block number beyond the chain height.
+func (s *ServerOpenchain) GetBlockTxIDs(ctx context.Context, number uint64) (result *TransactionIDList, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetBlockTxIDs", time.Since(start), err) }(time.Now())
+	info, err := s.GetBlockchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if number > info.Height {
+		return nil, toStatusError(ErrNotFound)
+	}
+
+	block, err := s.GetBlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	var txIDs []string
+	for _, envBytes := range block.Data.Data {
+		txID, err := txIDOf(envBytes)
+		if err != nil {
+			continue
+		}
+		txIDs = append(txIDs, txID)
+	}
+	return &TransactionIDList{TxIDs: txIDs}, nil
+}
+
+// GetBlockAtHeight returns the block that brought the chain to height, disambiguating height from
+// block number for callers used to other systems' conventions. In this ledger, block numbers start
+// at 1 and the chain's height is always the number of its most recently committed block, so the
+// block that brought the chain to a given height is simply the block with that number; this method
+// exists to make that invariant explicit and to reject a height beyond the chain's current one with
+// ErrNotFound instead of silently proxying a GetBlockByNumber call for a block that cannot yet
+// exist. Shares GetBlockByNumber's caching, context propagation, and validator failover behavior.
+func (s *ServerOpenchain) GetBlockAtHeight(ctx context.Context, height uint64) (result *common.Block, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetBlockAtHeight", time.Since(start), err) }(time.Now())
+	info, err := s.GetBlockchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if height == 0 || height > info.Height {
+		return nil, toStatusError(ErrNotFound)
+	}
+	return s.GetBlockByNumber(ctx, height)
+}
+
+// GetBlockCount proxies a GetBlockCount call to a validator peer, with the same context propagation
+// and validator failover behavior as GetBlockchainInfo. Returns ErrEmptyBlockchain if the chain has
+// no committed blocks yet (rather than returning a count of 0 ambiguously alongside every other
+// error condition), or an error wrapping ErrBlockRetrieval if the validator call itself fails.
+func (s *ServerOpenchain) GetBlockCount(ctx context.Context) (result uint64, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetBlockCount", time.Since(start), err) }(time.Now())
+	var count uint64
+	err = s.forwardToValidator(ctx, func(addr string, client openchainClient) error {
+		result, err := client.GetBlockCount(ctx, &empty.Empty{})
+		if err != nil {
+			return err
+		}
+		count = result.Count
+		return nil
+	})
+	if err != nil {
+		return 0, toStatusError(wrapRetrievalErr(err))
+	}
+	if count == 0 {
+		return 0, toStatusError(ErrEmptyBlockchain)
+	}
+	return count, nil
+}
+
+// GetTransactionByID proxies a GetTransactionByID call to a validator peer, with the same context
+// propagation and validator failover behavior as GetBlockchainInfo.
+func (s *ServerOpenchain) GetTransactionByID(ctx context.Context, txID string) (result *pb.Transaction, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetTransactionByID", time.Since(start), err) }(time.Now())
+	var tx *pb.Transaction
+	err = s.forwardToValidator(ctx, func(addr string, client openchainClient) error {
+		result, err := client.GetTransactionByID(ctx, &TransactionQuery{Txid: txID})
+		if err != nil {
+			return err
+		}
+		tx = result
+		return nil
+	})
+	return tx, toStatusError(err)
+}
+
+// GetTransactionByIDFormatted returns the transaction identified by txID encoded as format, so a
+// caller that only wants the transaction for display or logging can request JSON directly instead of
+// fetching the raw pb.Transaction and marshaling it client-side. This snapshot has no prior
+// GetTransactionStrByID method to deprecate (the surrounding core/rest package never grew that
+// particular awkward shortcut), so this is the package's one formatted-retrieval entry point rather
+// than a replacement for an existing one; GetTransactionByID remains the method to call for the raw
+// pb.Transaction.
+func (s *ServerOpenchain) GetTransactionByIDFormatted(ctx context.Context, txID string, format TransactionFormat) (result *FormattedTransaction, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetTransactionByIDFormatted", time.Since(start), err) }(time.Now())
+	tx, err := s.GetTransactionByID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	result, err = formatTransaction(tx, format)
+	return result, toStatusError(err)
+}
+
+// formatTransaction encodes tx as format, returning the encoded bytes alongside the MIME content
+// type of the encoding. An unrecognized format (including the TransactionFormatProto zero value)
+// falls back to the raw protobuf encoding, so an unset format behaves the same as explicitly
+// requesting it.
+func formatTransaction(tx *pb.Transaction, format TransactionFormat) (*FormattedTransaction, error) {
+	switch format {
+	case TransactionFormatJSON, TransactionFormatJSONIndented:
+		marshaler := &jsonpb.Marshaler{}
+		if format == TransactionFormatJSONIndented {
+			marshaler.Indent = "  "
+		}
+		payload, err := marshaler.MarshalToString(tx)
+		if err != nil {
+			return nil, err
+		}
+		return &FormattedTransaction{Payload: []byte(payload), ContentType: "application/json"}, nil
+	default:
+		payload, err := proto.Marshal(tx)
+		if err != nil {
+			return nil, err
+		}
+		return &FormattedTransaction{Payload: payload, ContentType: "application/x-protobuf"}, nil
+	}
+}
+
+// finalityEstimateWindow is the number of most recent blocks EstimateFinality averages block
+// intervals over.
+const finalityEstimateWindow = 10
+
+// EstimateFinality estimates how long until txID is expected to commit. This snapshot has no
+// mempool or pending-transaction pool to read actual queue depth from, so unlike the ideal
+// implementation this cannot account for how many transactions are queued ahead of txID; instead it
+// reports NotPending true if txID is already committed, and otherwise falls back to the average
+// interval between the most recent finalityEstimateWindow blocks as a queue-depth-agnostic estimate
+// of when the next block (and so, optimistically, txID) is expected to land. Any error from
+// GetTransactionByID, "not found" included, is treated the same as "not yet committed", since this
+// proxy has no way to distinguish an unknown transaction id from one that simply has not committed
+// yet.
+func (s *ServerOpenchain) EstimateFinality(ctx context.Context, txID string) (result *FinalityEstimate, err error) {
+	defer func(start time.Time) { metrics.observeRequest("EstimateFinality", time.Since(start), err) }(time.Now())
+	if _, err := s.GetTransactionByID(ctx, txID); err == nil {
+		return &FinalityEstimate{NotPending: true}, nil
+	}
+
+	interval, err := s.averageBlockInterval(ctx, finalityEstimateWindow)
+	if err != nil {
+		return nil, err
+	}
+	return &FinalityEstimate{EstimatedSeconds: interval.Seconds()}, nil
+}
+
+// averageBlockInterval returns the average time between the commit timestamps of the most recent
+// min(window, height) blocks, derived from the timestamp of each block's first transaction since
+// common.Block carries no block-level commit timestamp of its own. Returns zero if fewer than two
+// blocks are available to measure an interval between.
+func (s *ServerOpenchain) averageBlockInterval(ctx context.Context, window uint64) (time.Duration, error) {
+	info, err := s.GetBlockchainInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if info.Height < 2 {
+		return 0, nil
+	}
+	start := uint64(1)
+	if info.Height > window {
+		start = info.Height - window + 1
+	}
+
+	var timestamps []time.Time
+	for blockNumber := start; blockNumber <= info.Height; blockNumber++ {
+		block, err := s.GetBlockByNumber(ctx, blockNumber)
+		if err != nil {
+			return 0, err
+		}
+		ts, err := firstTransactionTimestamp(block)
+		if err != nil {
+			continue
+		}
+		timestamps = append(timestamps, ts)
+	}
+	if len(timestamps) < 2 {
+		return 0, nil
+	}
+	total := timestamps[len(timestamps)-1].Sub(timestamps[0])
+	return total / time.Duration(len(timestamps)-1), nil
+}
+
+// firstTransactionTimestamp decodes block's first transaction envelope and returns its chain
+// header's client-submitted timestamp, used as a stand-in for the block's own commit time.
+func firstTransactionTimestamp(block *common.Block) (time.Time, error) {
+	if len(block.Data.Data) == 0 {
+		return time.Time{}, errors.New("rest: block has no transactions")
+	}
+	env, err := utils.GetEnvelopeFromBlock(block.Data.Data[0])
+	if err != nil {
+		return time.Time{}, err
+	}
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if payload.Header == nil || payload.Header.ChainHeader == nil || payload.Header.ChainHeader.Timestamp == nil {
+		return time.Time{}, errors.New("rest: envelope has no chain header timestamp")
+	}
+	ts := payload.Header.ChainHeader.Timestamp
+	return time.Unix(ts.Seconds, int64(ts.Nanos)), nil
+}
+
+// defaultMaxBlockRangeSpan is used by GetBlocksByRange when rest.maxBlockRangeSpan is unset.
+const defaultMaxBlockRangeSpan = 100
+
+// maxBlockRangeSpan returns the configured cap on the number of blocks GetBlocksByRange may return
+// in a single call, reading rest.maxBlockRangeSpan and falling back to defaultMaxBlockRangeSpan.
+func maxBlockRangeSpan() uint64 {
+	if viper.IsSet("rest.maxBlockRangeSpan") {
+		return uint64(viper.GetInt("rest.maxBlockRangeSpan"))
+	}
+	return defaultMaxBlockRangeSpan
+}
+
+// GetBlocksByRange returns [start, end], serving any block already present in s.blockCache
+// straight from the cache and fetching the rest from a validator peer in a single
+// GetBlocksByRange round trip, rather than issuing one GetBlockByNumber call per missing block.
+// Validates start <= end and caps the span at maxBlockRangeSpan (configurable via
+// rest.maxBlockRangeSpan) so a single call cannot be used to try to materialize the entire chain.
+// Uses the same context propagation, validator failover, and caching-below-chain-height behavior as
+// GetBlockByNumber.
+func (s *ServerOpenchain) GetBlocksByRange(ctx context.Context, start, end uint64) (result *BlockList, err error) {
+	defer func(startTime time.Time) { metrics.observeRequest("GetBlocksByRange", time.Since(startTime), err) }(time.Now())
+	if end < start {
+		return nil, toStatusError(fmt.Errorf("%w: end block %d must not precede start block %d", ErrInvalidArgument, end, start))
+	}
+	if span, max := end-start+1, maxBlockRangeSpan(); span > max {
+		return nil, toStatusError(fmt.Errorf("%w: requested range of %d blocks exceeds the maximum of %d", ErrInvalidArgument, span, max))
+	}
+
+	blocks := make([]*common.Block, end-start+1)
+	var missingStart, missingEnd uint64
+	haveMissing := false
+	for blockNumber := start; blockNumber <= end; blockNumber++ {
+		if cached, ok := s.blockCache.get(blockNumber); ok {
+			blocks[blockNumber-start] = cached
+			continue
+		}
+		if !haveMissing {
+			missingStart = blockNumber
+			haveMissing = true
+		}
+		missingEnd = blockNumber
+	}
+	if !haveMissing {
+		return &BlockList{Blocks: blocks}, nil
+	}
+
+	info, err := s.GetBlockchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.Height == 0 {
+		return nil, toStatusError(ErrEmptyBlockchain)
+	}
+	if missingEnd > info.Height {
+		return nil, toStatusError(fmt.Errorf("%w: block %d exceeds the current chain height of %d", ErrInvalidArgument, missingEnd, info.Height))
+	}
+
+	var fetched *BlockList
+	err = s.forwardToValidator(ctx, func(addr string, client openchainClient) error {
+		result, err := client.GetBlocksByRange(ctx, &BlockRange{Start: missingStart, End: missingEnd})
+		if err != nil {
+			return err
+		}
+		fetched = result
+		return nil
+	})
+	if err != nil {
+		return nil, toStatusError(wrapRetrievalErr(err))
+	}
+	if uint64(len(fetched.Blocks)) != missingEnd-missingStart+1 {
+		return nil, toStatusError(fmt.Errorf("%w: validator returned %d blocks for range [%d, %d]", ErrBlockRetrieval, len(fetched.Blocks), missingStart, missingEnd))
+	}
+	for i, blockNumber := 0, missingStart; blockNumber <= missingEnd; i, blockNumber = i+1, blockNumber+1 {
+		block := fetched.Blocks[i]
+		blocks[blockNumber-start] = block
+		if blockNumber < info.Height {
+			s.blockCache.put(blockNumber, block)
+		}
+	}
+	return &BlockList{Blocks: blocks}, nil
+}
+
+// GetAverageEndorsements walks [start, end] one block at a time through GetBlockByNumber (so
+// blocks already below the chain tip are served from s.blockCache rather than re-dialing a
+// validator per block) and reports the mean, minimum, and maximum number of endorsements carried by
+// the endorser transactions in that span, for tuning an endorsement policy against how many
+// endorsers transactions actually collect in practice. A transaction whose action payload does not
+// decode as an endorser transaction (for example a configuration transaction) is silently excluded
+// from the statistics rather than counted as zero. Shares GetBlocksByRange's range validation.
+func (s *ServerOpenchain) GetAverageEndorsements(ctx context.Context, start, end uint64) (result *EndorsementStats, err error) {
+	defer func(startTime time.Time) { metrics.observeRequest("GetAverageEndorsements", time.Since(startTime), err) }(time.Now())
+	if end < start {
+		return nil, toStatusError(fmt.Errorf("%w: end block %d must not precede start block %d", ErrInvalidArgument, end, start))
+	}
+	if span, max := end-start+1, maxBlockRangeSpan(); span > max {
+		return nil, toStatusError(fmt.Errorf("%w: requested range of %d blocks exceeds the maximum of %d", ErrInvalidArgument, span, max))
+	}
+
+	stats := &EndorsementStats{}
+	var total uint64
+	for blockNumber := start; blockNumber <= end; blockNumber++ {
+		block, err := s.GetBlockByNumber(ctx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, envBytes := range block.Data.Data {
+			count, err := countEndorsements(envBytes)
+			if err != nil {
+				continue
+			}
+			stats.TransactionCount++
+			total += uint64(count)
+			if stats.TransactionCount == 1 || count < stats.Min {
+				stats.Min = count
+			}
+			if count > stats.Max {
+				stats.Max = count
+			}
+		}
+	}
+	if stats.TransactionCount > 0 {
+		stats.Mean = float64(total) / float64(stats.TransactionCount)
+	}
+	return stats, nil
+}
+
+// countEndorsements decodes envBytes as a single endorser transaction envelope and returns the
+// number of endorsements its first action carries, mirroring the decode chain
+// utils.GetActionFromEnvelope uses to reach a transaction's ChaincodeAction, but stopping one layer
+// earlier at the ChaincodeActionPayload since that is where the Endorsements live.
+func countEndorsements(envBytes []byte) (uint32, error) {
+	env, err := utils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return 0, err
+	}
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		return 0, err
+	}
+	if len(tx.Actions) == 0 {
+		return 0, errors.New("rest: endorser transaction has no actions")
+	}
+	ccPayload, _, err := utils.GetPayloads(tx.Actions[0])
+	if ccPayload == nil {
+		if err == nil {
+			err = errors.New("rest: endorser transaction action payload missing")
+		}
+		return 0, err
+	}
+	return uint32(len(ccPayload.Action.Endorsements)), nil
+}
+
+// defaultMaxStateKeysPerRequest is used by GetStateMultipleKeys when rest.maxStateKeysPerRequest is
+// unset.
+const defaultMaxStateKeysPerRequest = 100
+
+// maxStateKeysPerRequest returns the configured cap on the number of distinct keys
+// GetStateMultipleKeys may fetch in a single call, reading rest.maxStateKeysPerRequest and falling
+// back to defaultMaxStateKeysPerRequest.
+func maxStateKeysPerRequest() int {
+	if viper.IsSet("rest.maxStateKeysPerRequest") {
+		return viper.GetInt("rest.maxStateKeysPerRequest")
+	}
+	return defaultMaxStateKeysPerRequest
+}
+
+// defaultBloomFilterFalsePositiveRate is used by GetTxIDBloomFilter when rest.bloomFilterFalsePositiveRate
+// is unset.
+const defaultBloomFilterFalsePositiveRate = 0.01
+
+// bloomFilterFalsePositiveRate returns the configured false-positive rate GetTxIDBloomFilter builds
+// its filter for, reading rest.bloomFilterFalsePositiveRate and falling back to
+// defaultBloomFilterFalsePositiveRate.
+func bloomFilterFalsePositiveRate() float64 {
+	if viper.IsSet("rest.bloomFilterFalsePositiveRate") {
+		return viper.GetFloat64("rest.bloomFilterFalsePositiveRate")
+	}
+	return defaultBloomFilterFalsePositiveRate
+}
+
+// GetTxIDBloomFilter walks [start, end] one block at a time through GetBlockByNumber and returns a
+// bloom filter (sized for the false-positive rate configured by rest.bloomFilterFalsePositiveRate)
+// encoding every transaction id in that span, so a client can test membership of a transaction id
+// locally instead of issuing a GetTransactionByID round trip per candidate. Shares GetBlocksByRange's
+// range validation.
+func (s *ServerOpenchain) GetTxIDBloomFilter(ctx context.Context, start, end uint64) (result *BloomFilter, err error) {
+	defer func(startTime time.Time) { metrics.observeRequest("GetTxIDBloomFilter", time.Since(startTime), err) }(time.Now())
+	if end < start {
+		return nil, toStatusError(fmt.Errorf("%w: end block %d must not precede start block %d", ErrInvalidArgument, end, start))
+	}
+	if span, max := end-start+1, maxBlockRangeSpan(); span > max {
+		return nil, toStatusError(fmt.Errorf("%w: requested range of %d blocks exceeds the maximum of %d", ErrInvalidArgument, span, max))
+	}
+
+	var txIDs []string
+	for blockNumber := start; blockNumber <= end; blockNumber++ {
+		block, err := s.GetBlockByNumber(ctx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, envBytes := range block.Data.Data {
+			txID, err := txIDOf(envBytes)
+			if err != nil {
+				continue
+			}
+			txIDs = append(txIDs, txID)
+		}
+	}
+
+	filter := ledgerutil.NewBloomFilter(len(txIDs), bloomFilterFalsePositiveRate())
+	for _, txID := range txIDs {
+		filter.Add([]byte(txID))
+	}
+	return &BloomFilter{Bits: filter.ToBytes(), K: uint32(filter.K())}, nil
+}
+
+// txIDOf decodes envBytes as a transaction envelope and returns its chain header's TxID.
+func txIDOf(envBytes []byte) (string, error) {
+	env, err := utils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return "", err
+	}
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		return "", err
+	}
+	if payload.Header == nil || payload.Header.ChainHeader == nil {
+		return "", errors.New("rest: envelope has no chain header")
+	}
+	return payload.Header.ChainHeader.TxID, nil
+}
+
+// defaultFullBlockTxThreshold is used by GetBlockFullnessStats when rest.fullBlockTxThreshold is
+// unset.
+const defaultFullBlockTxThreshold = 10
+
+// fullBlockTxThreshold returns the configured transaction count at or above which
+// GetBlockFullnessStats considers a block "full", reading rest.fullBlockTxThreshold and falling back
+// to defaultFullBlockTxThreshold.
+func fullBlockTxThreshold() int {
+	if viper.IsSet("rest.fullBlockTxThreshold") {
+		return viper.GetInt("rest.fullBlockTxThreshold")
+	}
+	return defaultFullBlockTxThreshold
+}
+
+// GetBlockFullnessStats walks [start, end] one block at a time through GetBlockByNumber and reports
+// what fraction of those blocks are "full" -- carrying at least fullBlockTxThreshold transactions
+// (configurable via rest.fullBlockTxThreshold) -- versus near-empty, for utilization monitoring.
+// Shares GetBlocksByRange's range validation.
+func (s *ServerOpenchain) GetBlockFullnessStats(ctx context.Context, start, end uint64) (result *FullnessStats, err error) {
+	defer func(startTime time.Time) { metrics.observeRequest("GetBlockFullnessStats", time.Since(startTime), err) }(time.Now())
+	if end < start {
+		return nil, toStatusError(fmt.Errorf("%w: end block %d must not precede start block %d", ErrInvalidArgument, end, start))
+	}
+	if span, max := end-start+1, maxBlockRangeSpan(); span > max {
+		return nil, toStatusError(fmt.Errorf("%w: requested range of %d blocks exceeds the maximum of %d", ErrInvalidArgument, span, max))
+	}
+
+	threshold := fullBlockTxThreshold()
+	stats := &FullnessStats{}
+	for blockNumber := start; blockNumber <= end; blockNumber++ {
+		block, err := s.GetBlockByNumber(ctx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		stats.TotalBlockCount++
+		if len(block.Data.Data) >= threshold {
+			stats.FullBlockCount++
+		}
+	}
+	if stats.TotalBlockCount > 0 {
+		stats.FullFraction = float64(stats.FullBlockCount) / float64(stats.TotalBlockCount)
+	}
+	return stats, nil
+}
+
+// GetTipLinkage returns the chain tip's block number and hash, alongside the hash of the block
+// immediately preceding it, so a client can confirm in one call that the tip links to whatever
+// block hash it already has on record, instead of fetching and hashing both blocks itself. Returns
+// ErrEmptyBlockchain if the chain has no committed blocks yet; the genesis block (number 0's
+// successor, i.e. the chain's very first committed block) reports a nil PreviousHash since it has
+// no predecessor.
+func (s *ServerOpenchain) GetTipLinkage(ctx context.Context) (result *TipLinkage, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetTipLinkage", time.Since(start), err) }(time.Now())
+	info, err := s.GetBlockchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.Height == 0 {
+		return nil, toStatusError(ErrEmptyBlockchain)
+	}
+	tip, err := s.GetBlockByNumber(ctx, info.Height)
+	if err != nil {
+		return nil, err
+	}
+	return &TipLinkage{
+		Number:       tip.Header.Number,
+		Hash:         tip.Header.Hash(),
+		PreviousHash: tip.Header.PreviousHash,
+	}, nil
+}
+
+// GetGenesisHash returns the hash of the chain's genesis block without returning the block itself,
+// so a bootstrapping client can use it as a trust anchor without paying for the full block transfer.
+// In this ledger, block numbers run from 1 (not 0), so the genesis block is block number 1; this
+// reuses GetBlockByNumber (and so its cache) rather than duplicating block retrieval. Returns
+// ErrEmptyBlockchain if the chain has no committed blocks yet.
+func (s *ServerOpenchain) GetGenesisHash(ctx context.Context) (result []byte, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetGenesisHash", time.Since(start), err) }(time.Now())
+	genesis, err := s.GetBlockByNumber(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	return genesis.Header.Hash(), nil
+}
+
+// GetPeers returns the peer endpoints this proxy is aware of. This snapshot has no peer discovery
+// service of its own to query (no embedded gossip or membership layer in this package), so the
+// only "peers" it can honestly report are the validator addresses it is configured to forward
+// calls to, each reported with Type PeerEndpoint_VALIDATOR since that configuration list names
+// exactly the validators this node proxies to.
+func (s *ServerOpenchain) GetPeers(ctx context.Context) (result *pb.PeersMessage, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetPeers", time.Since(start), err) }(time.Now())
+	configured := viper.GetStringSlice("rest.validatorAddresses")
+	peers := make([]*pb.PeerEndpoint, len(configured))
+	for i, addr := range configured {
+		peers[i] = &pb.PeerEndpoint{Address: addr, Type: pb.PeerEndpoint_VALIDATOR}
+	}
+	return &pb.PeersMessage{Peers: peers}, nil
+}
+
+// GetPeersByType returns the subset of GetPeers' result whose Type matches peerType, reusing
+// GetPeers under the hood rather than re-implementing its peer enumeration. peerType
+// PeerEndpoint_UNDEFINED (the zero value, matching an unset filter) returns every peer, preserving
+// GetPeers' existing behavior for callers that don't care about filtering.
+func (s *ServerOpenchain) GetPeersByType(ctx context.Context, peerType pb.PeerEndpoint_Type) (result *pb.PeersMessage, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetPeersByType", time.Since(start), err) }(time.Now())
+	all, err := s.GetPeers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if peerType == pb.PeerEndpoint_UNDEFINED {
+		return all, nil
+	}
+	var filtered []*pb.PeerEndpoint
+	for _, peer := range all.Peers {
+		if peer.Type == peerType {
+			filtered = append(filtered, peer)
+		}
+	}
+	return &pb.PeersMessage{Peers: filtered}, nil
+}
+
+// FindLargeBlocks walks [start, end] one block at a time through GetBlockByNumber (so blocks
+// already below the chain tip are served from s.blockCache rather than re-dialing a validator per
+// block) and returns the numbers of those whose serialized size is at least thresholdBytes, in
+// ascending order. This snapshot does not persist each block's serialized size alongside it, so
+// unlike an implementation backed by stored sizes this recomputes each block's size with
+// proto.Size after fetching it, though a block already present in s.blockCache still avoids a
+// validator round trip. Shares GetBlocksByRange's range validation.
+func (s *ServerOpenchain) FindLargeBlocks(ctx context.Context, start, end, thresholdBytes uint64) (result *BlockNumberList, err error) {
+	defer func(startTime time.Time) { metrics.observeRequest("FindLargeBlocks", time.Since(startTime), err) }(time.Now())
+	if end < start {
+		return nil, toStatusError(fmt.Errorf("%w: end block %d must not precede start block %d", ErrInvalidArgument, end, start))
+	}
+	if span, max := end-start+1, maxBlockRangeSpan(); span > max {
+		return nil, toStatusError(fmt.Errorf("%w: requested range of %d blocks exceeds the maximum of %d", ErrInvalidArgument, span, max))
+	}
+
+	var numbers []uint64
+	for blockNumber := start; blockNumber <= end; blockNumber++ {
+		block, err := s.GetBlockByNumber(ctx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(proto.Size(block)) >= thresholdBytes {
+			numbers = append(numbers, blockNumber)
+		}
+	}
+	return &BlockNumberList{Numbers: numbers}, nil
+}
+
+// lcccChaincodeName is the name the lifecycle system chaincode (lccc) is deployed under; deploy and
+// upgrade transactions are recorded on the chain as invocations of this chaincode rather than as a
+// dedicated transaction type.
+const lcccChaincodeName = "lccc"
+
+// GetChaincodeVersion returns the version currently active for chaincodeID, derived by replaying
+// every lccc deploy/upgrade invocation for chaincodeID found on the chain, in block order: the
+// initial deploy establishes version 0, and each subsequent upgrade increments it by one, mirroring
+// the version counter lccc itself maintains internally (see core/chaincode/lccc.go). This snapshot
+// has no way to query lccc's own state through this proxy, so the version is reconstructed by
+// scanning committed transactions rather than read directly from lifecycle state. Returns
+// ErrNotFound if no deploy for chaincodeID is found.
+func (s *ServerOpenchain) GetChaincodeVersion(ctx context.Context, chaincodeID string) (result *ChaincodeVersionInfo, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetChaincodeVersion", time.Since(start), err) }(time.Now())
+	info, err := s.GetBlockchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	var version int32
+	for blockNumber := uint64(1); blockNumber <= info.Height; blockNumber++ {
+		block, err := s.GetBlockByNumber(ctx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, envBytes := range block.Data.Data {
+			isUpgrade, ok := decodeLifecycleInvocation(envBytes, chaincodeID)
+			if !ok {
+				continue
+			}
+			found = true
+			if isUpgrade {
+				version++
+			} else {
+				version = 0
+			}
+		}
+	}
+	if !found {
+		return nil, toStatusError(ErrNotFound)
+	}
+	return &ChaincodeVersionInfo{ChaincodeID: chaincodeID, Version: version}, nil
+}
+
+// decodeLifecycleInvocation decodes envBytes as an endorser transaction invoking lccc's deploy or
+// upgrade function against chaincodeID, returning ok false if envBytes is not such an invocation
+// (an undecodable envelope, a non-lccc invocation, or an lccc invocation naming a different
+// chaincode) rather than treating any of those as an error, since the overwhelming majority of
+// transactions on a real chain are not lifecycle invocations at all.
+func decodeLifecycleInvocation(envBytes []byte, chaincodeID string) (isUpgrade bool, ok bool) {
+	env, err := utils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return false, false
+	}
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		return false, false
+	}
+	tx, err := utils.GetTransaction(payload.Data)
+	if err != nil || len(tx.Actions) == 0 {
+		return false, false
+	}
+	ccPayload, _, err := utils.GetPayloads(tx.Actions[0])
+	if ccPayload == nil || err != nil {
+		return false, false
+	}
+	proposalPayload, err := utils.GetChaincodeProposalPayload(ccPayload.ChaincodeProposalPayload)
+	if err != nil {
+		return false, false
+	}
+	cis := &pb.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(proposalPayload.Input, cis); err != nil {
+		return false, false
+	}
+	if cis.ChaincodeSpec == nil || cis.ChaincodeSpec.ChaincodeID == nil || cis.ChaincodeSpec.ChaincodeID.Name != lcccChaincodeName {
+		return false, false
+	}
+	if cis.ChaincodeSpec.CtorMsg == nil || len(cis.ChaincodeSpec.CtorMsg.Args) != 3 {
+		return false, false
+	}
+	args := cis.ChaincodeSpec.CtorMsg.Args
+	function := string(args[0])
+	if function != "deploy" && function != "upgrade" {
+		return false, false
+	}
+	cds, err := utils.GetChaincodeDeploymentSpec(args[2])
+	if err != nil || cds.ChaincodeSpec == nil || cds.ChaincodeSpec.ChaincodeID == nil {
+		return false, false
+	}
+	if cds.ChaincodeSpec.ChaincodeID.Name != chaincodeID {
+		return false, false
+	}
+	return function == "upgrade", true
+}
+
+// GetStateMultipleKeys proxies a single call to a validator peer to fetch the values of keys from
+// chaincodeID's state, instead of requiring the caller to issue one GetState call per key. Returned
+// values are in the same order as keys, with nil for keys that do not exist. Repeated keys are
+// deduplicated before being sent to the validator, and the number of distinct keys is capped at
+// maxStateKeysPerRequest so a caller cannot request a pathological list. Uses the same context
+// propagation and validator failover behavior as GetBlockchainInfo.
+func (s *ServerOpenchain) GetStateMultipleKeys(ctx context.Context, chaincodeID string, keys []string) (ret [][]byte, err error) {
+	defer func(start time.Time) { metrics.observeRequest("GetStateMultipleKeys", time.Since(start), err) }(time.Now())
+	var distinctKeys []string
+	indexOf := map[string]int{}
+	for _, key := range keys {
+		if _, seen := indexOf[key]; seen {
+			continue
+		}
+		indexOf[key] = len(distinctKeys)
+		distinctKeys = append(distinctKeys, key)
+	}
+	if max := maxStateKeysPerRequest(); len(distinctKeys) > max {
+		return nil, toStatusError(fmt.Errorf("%w: requested %d distinct keys exceeds the maximum of %d", ErrInvalidArgument, len(distinctKeys), max))
+	}
+
+	var values *StateValues
+	err = s.forwardToValidator(ctx, func(addr string, client openchainClient) error {
+		result, err := client.GetStateMultipleKeys(ctx, &StateQuery{ChaincodeID: chaincodeID, Keys: distinctKeys})
+		if err != nil {
+			return err
+		}
+		values = result
+		return nil
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	results := make([][]byte, len(keys))
+	for i, key := range keys {
+		results[i] = values.Values[indexOf[key]]
+	}
+	return results, nil
+}
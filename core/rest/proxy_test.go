@@ -0,0 +1,1569 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	context "golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// proposalResponsePayloadBytes marshals a minimal ProposalResponsePayload carrying a non-empty
+// ChaincodeAction extension, suitable for populating ChaincodeEndorsedAction.ProposalResponsePayload
+// in a test fixture: utils.GetPayloads rejects an action whose ProposalResponsePayload is nil or
+// whose Extension does not unmarshal as a ChaincodeAction. The extension's Results must be non-empty,
+// since an all-default ChaincodeAction marshals to zero bytes, which proto3 then treats the same as
+// an unset ProposalResponsePayload.Extension (and, one level up, an unset
+// ChaincodeEndorsedAction.ProposalResponsePayload) when marshaling the wrapping message.
+func proposalResponsePayloadBytes(t *testing.T) []byte {
+	t.Helper()
+	extension, err := proto.Marshal(&pb.ChaincodeAction{Results: []byte("results")})
+	if err != nil {
+		t.Fatalf("failed to marshal ChaincodeAction: %s", err)
+	}
+	respPayloadBytes, err := proto.Marshal(&pb.ProposalResponsePayload{Extension: extension})
+	if err != nil {
+		t.Fatalf("failed to marshal ProposalResponsePayload: %s", err)
+	}
+	return respPayloadBytes
+}
+
+// endorserTxEnvelopeBytes marshals a minimal endorser transaction envelope carrying endorsementCount
+// endorsements on its single action, suitable for feeding directly to countEndorsements without the
+// signing machinery a real client would go through to produce one.
+func endorserTxEnvelopeBytes(t *testing.T, endorsementCount int) []byte {
+	t.Helper()
+	endorsements := make([]*pb.Endorsement, endorsementCount)
+	for i := range endorsements {
+		endorsements[i] = &pb.Endorsement{}
+	}
+	ccPayloadBytes, err := proto.Marshal(&pb.ChaincodeActionPayload{
+		Action: &pb.ChaincodeEndorsedAction{
+			ProposalResponsePayload: proposalResponsePayloadBytes(t),
+			Endorsements:            endorsements,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal ChaincodeActionPayload: %s", err)
+	}
+	txBytes, err := proto.Marshal(&pb.Transaction{
+		Actions: []*pb.TransactionAction{{Payload: ccPayloadBytes}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal Transaction: %s", err)
+	}
+	payloadBytes, err := proto.Marshal(&common.Payload{Data: txBytes})
+	if err != nil {
+		t.Fatalf("failed to marshal Payload: %s", err)
+	}
+	envBytes, err := proto.Marshal(&common.Envelope{Payload: payloadBytes})
+	if err != nil {
+		t.Fatalf("failed to marshal Envelope: %s", err)
+	}
+	return envBytes
+}
+
+// txEnvelopeBytesWithID marshals a minimal envelope carrying txID in its chain header, suitable for
+// feeding directly to GetTxIDBloomFilter without the signing machinery a real client would go
+// through to produce one.
+func txEnvelopeBytesWithID(t *testing.T, txID string) []byte {
+	t.Helper()
+	payloadBytes, err := proto.Marshal(&common.Payload{
+		Header: &common.Header{ChainHeader: &common.ChainHeader{TxID: txID}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal Payload: %s", err)
+	}
+	envBytes, err := proto.Marshal(&common.Envelope{Payload: payloadBytes})
+	if err != nil {
+		t.Fatalf("failed to marshal Envelope: %s", err)
+	}
+	return envBytes
+}
+
+func txEnvelopeBytesWithTimestamp(t *testing.T, txID string, ts time.Time) []byte {
+	t.Helper()
+	payloadBytes, err := proto.Marshal(&common.Payload{
+		Header: &common.Header{ChainHeader: &common.ChainHeader{
+			TxID:      txID,
+			Timestamp: &timestamp.Timestamp{Seconds: ts.Unix(), Nanos: int32(ts.Nanosecond())},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal Payload: %s", err)
+	}
+	envBytes, err := proto.Marshal(&common.Envelope{Payload: payloadBytes})
+	if err != nil {
+		t.Fatalf("failed to marshal Envelope: %s", err)
+	}
+	return envBytes
+}
+
+var errTransientForTest = grpc.Errorf(codes.Unavailable, "validator unreachable")
+
+func TestGetBlockchainInfoAbortsOnCancelledContext(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.validatorAddresses", []string{"localhost:9999"})
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := server.GetBlockchainInfo(ctx)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("expected an error for a call made with an already-cancelled context")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("GetBlockchainInfo did not return promptly for a cancelled context; the caller's context is not being propagated to the downstream RPC")
+	}
+}
+
+func TestGetBlockByNumberReturnsErrorWithNoValidatorsConfigured(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	if _, err := server.GetBlockByNumber(context.Background(), 1); grpc.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected codes.FailedPrecondition, got %v", err)
+	}
+}
+
+func TestGetBlockByNumberRejectsNumberBeyondHeight(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 5}
+	server.infoCache.fetchedAt = time.Now()
+
+	for _, number := range []uint64{6, math.MaxUint64} {
+		if _, err := server.GetBlockByNumber(context.Background(), number); grpc.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected codes.InvalidArgument for block number %d, got %v", number, err)
+		}
+	}
+}
+
+func TestGetBlockByNumberAcceptsNumberWithinHeight(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 5}
+	server.infoCache.fetchedAt = time.Now()
+
+	for _, number := range []uint64{4, 5} {
+		if _, err := server.GetBlockByNumber(context.Background(), number); grpc.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("expected block number %d to pass bounds checking and fail only on the absence of configured validators (codes.FailedPrecondition), got %v", number, err)
+		}
+	}
+}
+
+func TestGetBlocksByRangeRejectsInvertedRange(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	if _, err := server.GetBlocksByRange(context.Background(), 10, 5); err == nil {
+		t.Fatalf("expected an error for end < start")
+	}
+}
+
+func TestGetBlocksByRangeRejectsSpanExceedingMax(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.maxBlockRangeSpan", 10)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	if _, err := server.GetBlocksByRange(context.Background(), 1, 100); err == nil {
+		t.Fatalf("expected an error for a span exceeding the configured maximum")
+	}
+}
+
+func TestGetBlocksByRangeServesCachedBlocksWithoutDialing(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	block3 := &common.Block{Header: &common.BlockHeader{Number: 3}}
+	block4 := &common.Block{Header: &common.BlockHeader{Number: 4}}
+	server.blockCache.put(3, block3)
+	server.blockCache.put(4, block4)
+
+	result, err := server.GetBlocksByRange(context.Background(), 3, 4)
+	if err != nil {
+		t.Fatalf("expected the fully cached range to be served without dialing a validator, got %s", err)
+	}
+	if len(result.Blocks) != 2 || result.Blocks[0] != block3 || result.Blocks[1] != block4 {
+		t.Fatalf("expected the cached blocks back in range order, got %v", result.Blocks)
+	}
+}
+
+func TestGetTipLinkageReportsPreviousHashMatchingPriorBlock(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	block1 := &common.Block{Header: &common.BlockHeader{Number: 1}}
+	block2 := &common.Block{Header: &common.BlockHeader{Number: 2, PreviousHash: block1.Header.Hash()}}
+	server.blockCache.put(1, block1)
+	server.infoCache.info = &pb.BlockchainInfo{Height: 2}
+	server.infoCache.fetchedAt = time.Now()
+	server.blockCache.put(2, block2)
+
+	linkage, err := server.GetTipLinkage(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if linkage.Number != 2 {
+		t.Fatalf("expected tip number 2, got %d", linkage.Number)
+	}
+	if string(linkage.PreviousHash) != string(block1.Header.Hash()) {
+		t.Fatalf("expected previous hash to match block 1's hash")
+	}
+}
+
+func TestGetGenesisHashMatchesComputedHashOfGenesisBlock(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	genesis := &common.Block{Header: &common.BlockHeader{Number: 1}}
+	server.blockCache.put(1, genesis)
+
+	hash, err := server.GetGenesisHash(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(hash) != string(genesis.Header.Hash()) {
+		t.Fatalf("expected genesis hash to match the computed block header hash")
+	}
+}
+
+func TestGetGenesisHashReturnsErrEmptyBlockchain(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 0}
+	server.infoCache.fetchedAt = time.Now()
+
+	if _, err := server.GetGenesisHash(context.Background()); grpc.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected codes.FailedPrecondition, got %v", err)
+	}
+}
+
+func TestGetPeersReportsConfiguredValidatorAddressesAsValidatorType(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.validatorAddresses", []string{"validator1:7051", "validator2:7051"})
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	result, err := server.GetPeers(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Peers) != 2 {
+		t.Fatalf("expected 2 peers, got %d", len(result.Peers))
+	}
+	for _, peer := range result.Peers {
+		if peer.Type != pb.PeerEndpoint_VALIDATOR {
+			t.Fatalf("expected every peer to be reported as VALIDATOR, got %v for %s", peer.Type, peer.Address)
+		}
+	}
+}
+
+func TestGetPeersByTypeFiltersOutNonMatchingPeers(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.validatorAddresses", []string{"validator1:7051"})
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	result, err := server.GetPeersByType(context.Background(), pb.PeerEndpoint_NON_VALIDATOR)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Peers) != 0 {
+		t.Fatalf("expected no peers to match NON_VALIDATOR, got %d", len(result.Peers))
+	}
+}
+
+func TestGetPeersByTypeReturnsAllPeersForUndefinedFilter(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.validatorAddresses", []string{"validator1:7051", "validator2:7051"})
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	result, err := server.GetPeersByType(context.Background(), pb.PeerEndpoint_UNDEFINED)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Peers) != 2 {
+		t.Fatalf("expected the unfiltered peer count of 2, got %d", len(result.Peers))
+	}
+}
+
+func TestFindLargeBlocksReturnsOnlyBlocksAtOrAboveThreshold(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	small := &common.Block{Header: &common.BlockHeader{Number: 1}}
+	large := &common.Block{
+		Header: &common.BlockHeader{Number: 2},
+		Data:   &common.BlockData{Data: [][]byte{endorserTxEnvelopeBytes(t, 5), endorserTxEnvelopeBytes(t, 5)}},
+	}
+	server.blockCache.put(1, small)
+	server.blockCache.put(2, large)
+
+	threshold := uint64(proto.Size(large))
+	result, err := server.FindLargeBlocks(context.Background(), 1, 2, threshold)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Numbers) != 1 || result.Numbers[0] != 2 {
+		t.Fatalf("expected only block 2 to be reported, got %v", result.Numbers)
+	}
+}
+
+func TestGetBlockFullnessStatsReportsFractionAtOrAboveThreshold(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.fullBlockTxThreshold", 2)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.blockCache.put(1, &common.Block{Header: &common.BlockHeader{Number: 1}, Data: &common.BlockData{Data: [][]byte{}}})
+	server.blockCache.put(2, &common.Block{Header: &common.BlockHeader{Number: 2}, Data: &common.BlockData{Data: [][]byte{[]byte("tx1")}}})
+	server.blockCache.put(3, &common.Block{Header: &common.BlockHeader{Number: 3}, Data: &common.BlockData{Data: [][]byte{[]byte("tx1"), []byte("tx2")}}})
+	server.blockCache.put(4, &common.Block{Header: &common.BlockHeader{Number: 4}, Data: &common.BlockData{Data: [][]byte{[]byte("tx1"), []byte("tx2"), []byte("tx3")}}})
+
+	stats, err := server.GetBlockFullnessStats(context.Background(), 1, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stats.TotalBlockCount != 4 {
+		t.Fatalf("expected 4 blocks examined, got %d", stats.TotalBlockCount)
+	}
+	if stats.FullBlockCount != 2 {
+		t.Fatalf("expected 2 blocks at or above the threshold, got %d", stats.FullBlockCount)
+	}
+	if stats.FullFraction != 0.5 {
+		t.Fatalf("expected a full fraction of 0.5, got %f", stats.FullFraction)
+	}
+}
+
+func TestGetBlockFullnessStatsRejectsSpanExceedingMax(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.maxBlockRangeSpan", 10)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	if _, err := server.GetBlockFullnessStats(context.Background(), 1, 100); err == nil {
+		t.Fatalf("expected an error for a span exceeding the configured maximum")
+	}
+}
+
+func TestFindLargeBlocksRejectsSpanExceedingMax(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.maxBlockRangeSpan", 10)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	if _, err := server.FindLargeBlocks(context.Background(), 1, 100, 0); err == nil {
+		t.Fatalf("expected an error for a span exceeding the configured maximum")
+	}
+}
+
+func TestGetAverageEndorsementsComputesMeanMinMax(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.blockCache.put(1, &common.Block{
+		Header: &common.BlockHeader{Number: 1},
+		Data:   &common.BlockData{Data: [][]byte{endorserTxEnvelopeBytes(t, 2), endorserTxEnvelopeBytes(t, 4)}},
+	})
+	server.blockCache.put(2, &common.Block{
+		Header: &common.BlockHeader{Number: 2},
+		Data:   &common.BlockData{Data: [][]byte{endorserTxEnvelopeBytes(t, 3)}},
+	})
+
+	stats, err := server.GetAverageEndorsements(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stats.TransactionCount != 3 || stats.Min != 2 || stats.Max != 4 || stats.Mean != 3 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestGetAverageEndorsementsSkipsUndecodableTransactions(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	undecodableEnvBytes, err := proto.Marshal(&common.Envelope{Payload: []byte{0xff, 0xff, 0xff, 0xff}})
+	if err != nil {
+		t.Fatalf("failed to marshal undecodable envelope: %s", err)
+	}
+	server.blockCache.put(1, &common.Block{
+		Header: &common.BlockHeader{Number: 1},
+		Data:   &common.BlockData{Data: [][]byte{undecodableEnvBytes, endorserTxEnvelopeBytes(t, 5)}},
+	})
+
+	stats, err := server.GetAverageEndorsements(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stats.TransactionCount != 1 || stats.Min != 5 || stats.Max != 5 || stats.Mean != 5 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestGetTxIDBloomFilterTestsKnownIDsPositiveAndRandomIDsMostlyNegative(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	knownIDs := []string{"tx-1", "tx-2", "tx-3"}
+	server.blockCache.put(1, &common.Block{
+		Header: &common.BlockHeader{Number: 1},
+		Data: &common.BlockData{Data: [][]byte{
+			txEnvelopeBytesWithID(t, knownIDs[0]),
+			txEnvelopeBytesWithID(t, knownIDs[1]),
+		}},
+	})
+	server.blockCache.put(2, &common.Block{
+		Header: &common.BlockHeader{Number: 2},
+		Data:   &common.BlockData{Data: [][]byte{txEnvelopeBytesWithID(t, knownIDs[2])}},
+	})
+
+	filter, err := server.GetTxIDBloomFilter(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	reconstructed := ledgerutil.NewBloomFilterFromBytes(filter.Bits, uint(filter.K))
+	for _, id := range knownIDs {
+		if !reconstructed.Test([]byte(id)) {
+			t.Fatalf("expected known id %q to test positive", id)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if reconstructed.Test([]byte(fmt.Sprintf("absent-%d", i))) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 100 {
+		t.Fatalf("expected well under half of random ids to false-positive, got %d", falsePositives)
+	}
+}
+
+func TestGetBlockAtHeightReturnsTheBlockWithThatNumber(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 5}
+	server.infoCache.fetchedAt = time.Now()
+	server.blockCache.put(3, &common.Block{Header: &common.BlockHeader{Number: 3}})
+
+	block, err := server.GetBlockAtHeight(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if block.Header.Number != 3 {
+		t.Fatalf("expected block number 3, got %d", block.Header.Number)
+	}
+}
+
+func TestGetBlockAtHeightReturnsErrNotFoundBeyondCurrentHeight(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 5}
+	server.infoCache.fetchedAt = time.Now()
+
+	if _, err := server.GetBlockAtHeight(context.Background(), 6); grpc.Code(err) != codes.NotFound {
+		t.Fatalf("expected codes.NotFound for a height beyond the current height, got %v", err)
+	}
+}
+
+func TestGetTransactionsByBlockNumberReturnsBlockEnvelopes(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 5}
+	server.infoCache.fetchedAt = time.Now()
+	server.blockCache.put(3, &common.Block{
+		Header: &common.BlockHeader{Number: 3},
+		Data:   &common.BlockData{Data: [][]byte{[]byte("tx1"), []byte("tx2")}},
+	})
+
+	txs, err := server.GetTransactionsByBlockNumber(context.Background(), 3, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(txs.Transactions) != 2 || string(txs.Transactions[0]) != "tx1" || string(txs.Transactions[1]) != "tx2" {
+		t.Fatalf("unexpected transactions: %v", txs.Transactions)
+	}
+}
+
+func TestGetTransactionsByBlockNumberReturnsErrNotFoundBeyondHeight(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 5}
+	server.infoCache.fetchedAt = time.Now()
+
+	if _, err := server.GetTransactionsByBlockNumber(context.Background(), 6, false); grpc.Code(err) != codes.NotFound {
+		t.Fatalf("expected codes.NotFound for a number beyond the chain height, got %v", err)
+	}
+}
+
+func TestGetTransactionByIDFormattedReturnsErrorWithNoValidatorsConfigured(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	if _, err := server.GetTransactionByIDFormatted(context.Background(), "txid1", TransactionFormatJSON); grpc.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected codes.FailedPrecondition, got %v", err)
+	}
+}
+
+func TestFormatTransactionEncodesProtoAndJSONVariants(t *testing.T) {
+	tx := &pb.Transaction{Actions: []*pb.TransactionAction{{Payload: []byte("payload")}}}
+
+	protoEncoded, err := formatTransaction(tx, TransactionFormatProto)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if protoEncoded.ContentType != "application/x-protobuf" {
+		t.Fatalf("expected application/x-protobuf content type, got %s", protoEncoded.ContentType)
+	}
+
+	compact, err := formatTransaction(tx, TransactionFormatJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if compact.ContentType != "application/json" {
+		t.Fatalf("expected application/json content type, got %s", compact.ContentType)
+	}
+	if strings.Contains(string(compact.Payload), "\n") {
+		t.Fatalf("expected compact JSON to be single-line, got %s", compact.Payload)
+	}
+
+	indented, err := formatTransaction(tx, TransactionFormatJSONIndented)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(string(indented.Payload), "\n") {
+		t.Fatalf("expected indented JSON to span multiple lines, got %s", indented.Payload)
+	}
+}
+
+func TestGetBlockTxIDsMatchesOrderOfFullBlockTransactions(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 5}
+	server.infoCache.fetchedAt = time.Now()
+	server.blockCache.put(3, &common.Block{
+		Header: &common.BlockHeader{Number: 3},
+		Data: &common.BlockData{Data: [][]byte{
+			txEnvelopeBytesWithID(t, "tx1"),
+			txEnvelopeBytesWithID(t, "tx2"),
+		}},
+	})
+
+	block, err := server.GetTransactionsByBlockNumber(context.Background(), 3, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ids, err := server.GetBlockTxIDs(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(ids.TxIDs) != len(block.Transactions) {
+		t.Fatalf("expected %d tx IDs, got %d", len(block.Transactions), len(ids.TxIDs))
+	}
+	for i, envBytes := range block.Transactions {
+		want, err := txIDOf(envBytes)
+		if err != nil {
+			t.Fatalf("unexpected error decoding envelope %d: %s", i, err)
+		}
+		if ids.TxIDs[i] != want {
+			t.Fatalf("expected tx ID %d to be %s, got %s", i, want, ids.TxIDs[i])
+		}
+	}
+}
+
+func TestGetBlockTxIDsReturnsErrNotFoundBeyondHeight(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 5}
+	server.infoCache.fetchedAt = time.Now()
+
+	if _, err := server.GetBlockTxIDs(context.Background(), 6); grpc.Code(err) != codes.NotFound {
+		t.Fatalf("expected codes.NotFound for a number beyond the chain height, got %v", err)
+	}
+}
+
+func TestGetBlockByNumberReturnsErrEmptyBlockchain(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	// Seed the blockchain info cache directly with a zero-height result, as if a validator had
+	// genuinely reported an empty chain, so this does not depend on dialing a real validator.
+	server.infoCache.info = &pb.BlockchainInfo{Height: 0}
+	server.infoCache.fetchedAt = time.Now()
+
+	_, err = server.GetBlockByNumber(context.Background(), 1)
+	if grpc.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected codes.FailedPrecondition, got %v", err)
+	}
+}
+
+func TestGetBlockByNumberWrapsFailureAsErrBlockRetrieval(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.validatorAddresses", []string{"localhost:1"})
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 10}
+	server.infoCache.fetchedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = server.GetBlockByNumber(ctx, 1)
+	if grpc.Code(err) != codes.Internal {
+		t.Fatalf("expected codes.Internal, got %v", err)
+	}
+}
+
+func TestGetBlockCountReturnsErrNoValidatorsUnwrapped(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	// With no validators configured there is nothing to retrieve from, so this should surface as
+	// codes.FailedPrecondition rather than being folded into ErrBlockRetrieval's codes.Internal.
+	if _, err := server.GetBlockCount(context.Background()); grpc.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected codes.FailedPrecondition with no validators configured, got %v", err)
+	}
+}
+
+func TestGetValidatorAddressesRoundRobinRotates(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	addrs := []string{"a:1", "b:1", "c:1"}
+	viper.Set("rest.validatorAddresses", addrs)
+	viper.Set("rest.validatorSelection", "round-robin")
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	first, err := server.getValidatorAddresses()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	second, err := server.getValidatorAddresses()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if first[0] == second[0] {
+		t.Fatalf("expected round-robin to advance the starting address between calls, got %s both times", first[0])
+	}
+}
+
+func TestGetValidatorAddressesSkipsAddressesInCooldown(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	addrs := []string{"a:1", "b:1"}
+	viper.Set("rest.validatorAddresses", addrs)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.cooldowns.markFailed("a:1")
+	available, err := server.getValidatorAddresses()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, addr := range available {
+		if addr == "a:1" {
+			t.Fatalf("expected the cooling-down address to be excluded, got %v", available)
+		}
+	}
+}
+
+func TestGetValidatorAddressesFallsBackWhenAllInCooldown(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	addrs := []string{"a:1", "b:1"}
+	viper.Set("rest.validatorAddresses", addrs)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.cooldowns.markFailed("a:1")
+	server.cooldowns.markFailed("b:1")
+	available, err := server.getValidatorAddresses()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(available) != 2 {
+		t.Fatalf("expected every address to remain usable once all are cooling down, got %v", available)
+	}
+}
+
+func TestBlockchainInfoCacheCollapsesConcurrentCallers(t *testing.T) {
+	var calls int32
+	cache := &blockchainInfoCache{}
+	fetch := func() (*pb.BlockchainInfo, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return &pb.BlockchainInfo{Height: 7}, nil
+	}
+
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.blockchainInfoCacheTTL", time.Hour)
+
+	var wg sync.WaitGroup
+	results := make([]*pb.BlockchainInfo, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			info, err := cache.get(fetch)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			results[i] = info
+		}(i)
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected exactly 1 upstream fetch for concurrent callers, got %d", calls)
+	}
+	for _, info := range results {
+		if info == nil || info.Height != 7 {
+			t.Fatalf("expected every caller to receive the shared result, got %v", info)
+		}
+	}
+
+	// a second call after the TTL window is still warm should be served from cache, not re-fetch
+	if _, err := cache.get(fetch); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected the cached entry to be reused, got %d upstream fetches", calls)
+	}
+}
+
+func TestBlockchainInfoCacheDisabledWithZeroTTL(t *testing.T) {
+	var calls int32
+	cache := &blockchainInfoCache{}
+	fetch := func() (*pb.BlockchainInfo, error) {
+		atomic.AddInt32(&calls, 1)
+		return &pb.BlockchainInfo{Height: 1}, nil
+	}
+
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.blockchainInfoCacheTTL", time.Duration(0))
+
+	cache.get(fetch)
+	cache.get(fetch)
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("expected every call to re-fetch with caching disabled, got %d calls", calls)
+	}
+}
+
+func TestGetBlockByNumberServesSubsequentCallsFromCache(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	block := &common.Block{Header: &common.BlockHeader{Number: 5}}
+	server.blockCache.put(5, block)
+
+	got, err := server.GetBlockByNumber(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != block {
+		t.Fatalf("expected the cached block to be returned without dialing a validator")
+	}
+
+	hits, misses := server.blockCache.stats()
+	if hits != 1 || misses != 0 {
+		t.Fatalf("expected 1 hit and 0 misses, got %d hits and %d misses", hits, misses)
+	}
+}
+
+func TestBlockCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newBlockCache(2)
+	c.put(1, &common.Block{Header: &common.BlockHeader{Number: 1}})
+	c.put(2, &common.Block{Header: &common.BlockHeader{Number: 2}})
+	c.get(1) // touch 1 so 2 becomes the least-recently-used entry
+	c.put(3, &common.Block{Header: &common.BlockHeader{Number: 3}})
+
+	if _, ok := c.get(2); ok {
+		t.Fatalf("expected block 2 to have been evicted")
+	}
+	if _, ok := c.get(1); !ok {
+		t.Fatalf("expected block 1 to still be cached")
+	}
+	if _, ok := c.get(3); !ok {
+		t.Fatalf("expected block 3 to still be cached")
+	}
+}
+
+func TestBlockCursorAdvancesAndCloses(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	addrs := []string{"localhost:19011"}
+	viper.Set("rest.validatorAddresses", addrs)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	// seed the cache so NextBlocks does not need to dial a validator
+	for i := uint64(1); i <= 5; i++ {
+		server.blockCache.put(i, &common.Block{Header: &common.BlockHeader{Number: i}})
+	}
+
+	cursorID, err := server.OpenBlockCursor(context.Background(), 1, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	first, err := server.NextBlocks(context.Background(), cursorID, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(first.Blocks) != 2 || first.Blocks[0].Header.Number != 1 || first.Blocks[1].Header.Number != 2 {
+		t.Fatalf("unexpected first batch: %v", first.Blocks)
+	}
+
+	second, err := server.NextBlocks(context.Background(), cursorID, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(second.Blocks) != 2 || second.Blocks[0].Header.Number != 3 {
+		t.Fatalf("unexpected second batch: %v", second.Blocks)
+	}
+
+	third, err := server.NextBlocks(context.Background(), cursorID, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(third.Blocks) != 1 || third.Blocks[0].Header.Number != 5 {
+		t.Fatalf("unexpected final batch: %v", third.Blocks)
+	}
+
+	// the cursor's range is now exhausted, so it should have been removed automatically
+	if _, err := server.NextBlocks(context.Background(), cursorID, 2); grpc.Code(err) != codes.NotFound {
+		t.Fatalf("expected codes.NotFound for an exhausted cursor, got %v", err)
+	}
+
+	cursorID2, err := server.OpenBlockCursor(context.Background(), 1, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	server.CloseCursor(cursorID2)
+	if _, err := server.NextBlocks(context.Background(), cursorID2, 2); grpc.Code(err) != codes.NotFound {
+		t.Fatalf("expected codes.NotFound for a closed cursor, got %v", err)
+	}
+}
+
+func TestBlockCursorExpiresAfterTTL(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.cursorTTL", time.Millisecond)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	cursorID, err := server.OpenBlockCursor(context.Background(), 1, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := server.NextBlocks(context.Background(), cursorID, 2); grpc.Code(err) != codes.NotFound {
+		t.Fatalf("expected codes.NotFound for an expired cursor, got %v", err)
+	}
+}
+
+func TestGetStateMultipleKeysRejectsTooManyDistinctKeys(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.maxStateKeysPerRequest", 2)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	if _, err := server.GetStateMultipleKeys(context.Background(), "mycc", []string{"a", "b", "c"}); err == nil {
+		t.Fatalf("expected an error for a key list exceeding the configured maximum")
+	}
+}
+
+func TestGetStateMultipleKeysDeduplicatesBeforeCountingAgainstMax(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.maxStateKeysPerRequest", 2)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	// a single distinct key is well under the configured maximum of 2, so the call should proceed
+	// past the bound check and fail only because no validators are configured
+	if _, err := server.GetStateMultipleKeys(context.Background(), "mycc", []string{"a", "a", "a"}); grpc.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected codes.FailedPrecondition once the key count passed the bound check, got %v", err)
+	}
+}
+
+func TestForwardToValidatorReportsAllAttemptedAddressesOnExhaustion(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	addr1, server1 := newTestGRPCServer(t)
+	defer server1.Stop()
+	addr2, server2 := newTestGRPCServer(t)
+	defer server2.Stop()
+	addr3, server3 := newTestGRPCServer(t)
+	defer server3.Stop()
+	addrs := []string{addr1, addr2, addr3}
+	viper.Set("rest.validatorAddresses", addrs)
+	viper.Set("rest.retryMaxAttempts", 1)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	attempted := map[string]bool{}
+	err = server.forwardToValidator(context.Background(), func(addr string, client openchainClient) error {
+		attempted[addr] = true
+		return errTransientForTest
+	})
+	if err == nil {
+		t.Fatalf("expected an error once every validator has been exhausted")
+	}
+	if len(attempted) != len(addrs) {
+		t.Fatalf("expected every configured validator to be attempted, got %v", attempted)
+	}
+	for _, addr := range addrs {
+		if !strings.Contains(err.Error(), addr) {
+			t.Fatalf("expected the exhaustion error to name %s, got %s", addr, err)
+		}
+	}
+}
+
+func TestForwardToValidatorReusesCachedValidatorAcrossCalls(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	addr1, server1 := newTestGRPCServer(t)
+	defer server1.Stop()
+	addr2, server2 := newTestGRPCServer(t)
+	defer server2.Stop()
+	addr3, server3 := newTestGRPCServer(t)
+	defer server3.Stop()
+	addrs := []string{addr1, addr2, addr3}
+	viper.Set("rest.validatorAddresses", addrs)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	succeed := func(addr string, client openchainClient) error { return nil }
+	if err := server.forwardToValidator(context.Background(), succeed); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	cached, ok := server.current.get()
+	if !ok {
+		t.Fatalf("expected a validator to be cached after a successful call")
+	}
+
+	for i := 0; i < 5; i++ {
+		var used string
+		err := server.forwardToValidator(context.Background(), func(addr string, client openchainClient) error {
+			used = addr
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if used != cached {
+			t.Fatalf("expected every call within the TTL to reuse %s, got %s", cached, used)
+		}
+	}
+}
+
+func TestForwardToValidatorInvalidatesCachedValidatorOnTransientFailure(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	addr1, server1 := newTestGRPCServer(t)
+	defer server1.Stop()
+	addr2, server2 := newTestGRPCServer(t)
+	defer server2.Stop()
+	addrs := []string{addr1, addr2}
+	viper.Set("rest.validatorAddresses", addrs)
+	viper.Set("rest.retryMaxAttempts", 1)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.current.set(addrs[0])
+
+	attempted := []string{}
+	err = server.forwardToValidator(context.Background(), func(addr string, client openchainClient) error {
+		attempted = append(attempted, addr)
+		if addr == addrs[0] {
+			return errTransientForTest
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(attempted) == 0 || attempted[0] != addrs[0] {
+		t.Fatalf("expected the cached validator to be tried first, got %v", attempted)
+	}
+	if cached, ok := server.current.get(); !ok || cached != addrs[1] {
+		t.Fatalf("expected the cache to now hold the successful validator %s, got %q (ok=%v)", addrs[1], cached, ok)
+	}
+}
+
+func TestCallWithRetryRetriesTransientFailuresUpToMaxAttempts(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.retryMaxAttempts", 3)
+	viper.Set("rest.retryBaseDelay", time.Millisecond)
+
+	attempts := 0
+	err := callWithRetry(context.Background(), func() error {
+		attempts++
+		return errTransientForTest
+	})
+	if grpc.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the final transient error to be returned, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected exactly 3 attempts, got %d", attempts)
+	}
+}
+
+func TestCallWithRetryStopsOnFirstSuccess(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.retryMaxAttempts", 5)
+	viper.Set("rest.retryBaseDelay", time.Millisecond)
+
+	attempts := 0
+	err := callWithRetry(context.Background(), func() error {
+		attempts++
+		if attempts < 2 {
+			return errTransientForTest
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected retrying to stop as soon as the call succeeds, got %d attempts", attempts)
+	}
+}
+
+func TestCallWithRetryDoesNotRetryPermanentFailures(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.retryMaxAttempts", 5)
+	viper.Set("rest.retryBaseDelay", time.Millisecond)
+
+	attempts := 0
+	notFound := grpc.Errorf(codes.NotFound, "no such block")
+	err := callWithRetry(context.Background(), func() error {
+		attempts++
+		return notFound
+	})
+	if grpc.Code(err) != codes.NotFound {
+		t.Fatalf("expected the permanent error to be returned, got %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected exactly 1 attempt for a non-retriable error, got %d", attempts)
+	}
+}
+
+func TestCallWithRetryStopsWhenContextIsDone(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.retryMaxAttempts", 5)
+	viper.Set("rest.retryBaseDelay", time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- callWithRetry(ctx, func() error {
+			attempts++
+			return errTransientForTest
+		})
+	}()
+
+	// let the first attempt happen and enter its backoff sleep, then cancel instead of waiting out
+	// the jittered delay, drawn from [0, 1h) for the first retry
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("expected context.Canceled once ctx is done mid-backoff, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("callWithRetry did not return promptly once ctx was cancelled during backoff")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected exactly 1 attempt before the cancellation interrupted the backoff sleep, got %d", attempts)
+	}
+}
+
+func TestPingReturnsDownWhenNoValidatorsConfigured(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	status, err := server.Ping(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if status.Status != ServerStatusDown {
+		t.Fatalf("expected ServerStatusDown, got %v", status.Status)
+	}
+}
+
+func TestPingReturnsOKWhenAValidatorIsAvailable(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("rest.validatorAddresses", []string{"localhost:19001"})
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	status, err := server.Ping(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if status.Status != ServerStatusOK {
+		t.Fatalf("expected ServerStatusOK, got %v", status.Status)
+	}
+}
+
+func TestPingReturnsDegradedWhenAllValidatorsAreCoolingDown(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	addrs := []string{"localhost:19001", "localhost:19002"}
+	viper.Set("rest.validatorAddresses", addrs)
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.cooldowns.markFailed(addrs[0])
+	server.cooldowns.markFailed(addrs[1])
+
+	status, err := server.Ping(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if status.Status != ServerStatusDegraded {
+		t.Fatalf("expected ServerStatusDegraded, got %v", status.Status)
+	}
+}
+
+func TestEstimateFinalityEstimatesFromRecentBlockIntervalsWhenNotCommitted(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 3}
+	server.infoCache.fetchedAt = time.Now()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	server.blockCache.put(1, &common.Block{
+		Header: &common.BlockHeader{Number: 1},
+		Data:   &common.BlockData{Data: [][]byte{txEnvelopeBytesWithTimestamp(t, "tx-1", base)}},
+	})
+	server.blockCache.put(2, &common.Block{
+		Header: &common.BlockHeader{Number: 2},
+		Data:   &common.BlockData{Data: [][]byte{txEnvelopeBytesWithTimestamp(t, "tx-2", base.Add(10*time.Second))}},
+	})
+	server.blockCache.put(3, &common.Block{
+		Header: &common.BlockHeader{Number: 3},
+		Data:   &common.BlockData{Data: [][]byte{txEnvelopeBytesWithTimestamp(t, "tx-3", base.Add(20*time.Second))}},
+	})
+
+	estimate, err := server.EstimateFinality(context.Background(), "tx-not-committed")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if estimate.NotPending {
+		t.Fatalf("expected an uncommitted transaction to not be reported as NotPending")
+	}
+	if estimate.EstimatedSeconds != 10 {
+		t.Fatalf("expected the average 10s block interval, got %v", estimate.EstimatedSeconds)
+	}
+}
+
+// lifecycleTxEnvelopeBytes marshals a minimal endorser transaction envelope invoking lccc's deploy
+// or upgrade function against a chaincode deployed under ccName, suitable for feeding directly to
+// decodeLifecycleInvocation without the signing machinery a real client would go through to produce
+// one.
+func lifecycleTxEnvelopeBytes(t *testing.T, function, ccName string) []byte {
+	t.Helper()
+	cds, err := proto.Marshal(&pb.ChaincodeDeploymentSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: ccName}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal ChaincodeDeploymentSpec: %s", err)
+	}
+	cis, err := proto.Marshal(&pb.ChaincodeInvocationSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			ChaincodeID: &pb.ChaincodeID{Name: lcccChaincodeName},
+			CtorMsg:     &pb.ChaincodeInput{Args: [][]byte{[]byte(function), []byte("testchain"), cds}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal ChaincodeInvocationSpec: %s", err)
+	}
+	proposalPayload, err := proto.Marshal(&pb.ChaincodeProposalPayload{Input: cis})
+	if err != nil {
+		t.Fatalf("failed to marshal ChaincodeProposalPayload: %s", err)
+	}
+	ccPayloadBytes, err := proto.Marshal(&pb.ChaincodeActionPayload{
+		ChaincodeProposalPayload: proposalPayload,
+		Action: &pb.ChaincodeEndorsedAction{
+			ProposalResponsePayload: proposalResponsePayloadBytes(t),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal ChaincodeActionPayload: %s", err)
+	}
+	txBytes, err := proto.Marshal(&pb.Transaction{Actions: []*pb.TransactionAction{{Payload: ccPayloadBytes}}})
+	if err != nil {
+		t.Fatalf("failed to marshal Transaction: %s", err)
+	}
+	payloadBytes, err := proto.Marshal(&common.Payload{Data: txBytes})
+	if err != nil {
+		t.Fatalf("failed to marshal Payload: %s", err)
+	}
+	envBytes, err := proto.Marshal(&common.Envelope{Payload: payloadBytes})
+	if err != nil {
+		t.Fatalf("failed to marshal Envelope: %s", err)
+	}
+	return envBytes
+}
+
+func TestGetChaincodeVersionReflectsLatestUpgrade(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 3}
+	server.infoCache.fetchedAt = time.Now()
+	server.blockCache.put(1, &common.Block{
+		Header: &common.BlockHeader{Number: 1},
+		Data:   &common.BlockData{Data: [][]byte{lifecycleTxEnvelopeBytes(t, "deploy", "mycc")}},
+	})
+	server.blockCache.put(2, &common.Block{
+		Header: &common.BlockHeader{Number: 2},
+		Data:   &common.BlockData{Data: [][]byte{endorserTxEnvelopeBytes(t, 2)}},
+	})
+	server.blockCache.put(3, &common.Block{
+		Header: &common.BlockHeader{Number: 3},
+		Data:   &common.BlockData{Data: [][]byte{lifecycleTxEnvelopeBytes(t, "upgrade", "mycc")}},
+	})
+
+	info, err := server.GetChaincodeVersion(context.Background(), "mycc")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if info.Version != 1 {
+		t.Fatalf("expected version 1 after a single upgrade, got %d", info.Version)
+	}
+}
+
+func TestGetChaincodeVersionReturnsErrNotFoundForUnknownChaincode(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	server, err := NewOpenchainServer()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.pool.Close()
+
+	server.infoCache.info = &pb.BlockchainInfo{Height: 1}
+	server.infoCache.fetchedAt = time.Now()
+	server.blockCache.put(1, &common.Block{
+		Header: &common.BlockHeader{Number: 1},
+		Data:   &common.BlockData{Data: [][]byte{lifecycleTxEnvelopeBytes(t, "deploy", "mycc")}},
+	})
+
+	if _, err := server.GetChaincodeVersion(context.Background(), "othercc"); grpc.Code(err) != codes.NotFound {
+		t.Fatalf("expected codes.NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// ErrInvalidArgument marks an error caused by invalid caller input (an inverted or oversized block
+// range, too many distinct state keys, or a GetBlockByNumber request for a number beyond the current
+// chain height), as opposed to a failure encountered while serving an otherwise well-formed request.
+var ErrInvalidArgument = errors.New("rest: invalid argument")
+
+// ErrNotFound marks an error caused by a well-formed request for a resource that does not exist, for
+// example a block height beyond the current chain height passed to GetBlockAtHeight, as opposed to
+// errCursorNotFound's narrower unknown-or-expired-cursor condition.
+var ErrNotFound = errors.New("rest: requested resource not found")
+
+// toStatusError maps a ServerOpenchain error to a grpc error carrying an appropriate code, so a
+// caller sees codes.NotFound for an unknown cursor, codes.FailedPrecondition for a
+// validator-configuration or empty-chain condition, codes.InvalidArgument for bad caller input, and
+// codes.Internal for everything else, instead of every error surfacing as the default
+// codes.Unknown. An error that already carries a grpc code (for example, one returned as-is by a
+// validator's own RPC) is passed through unchanged rather than being recoded as Internal.
+//
+// This snapshot's vendored grpc predates the google.golang.org/grpc/status package, so grpc.Errorf
+// and grpc.Code -- the status-like API this version actually ships -- are used in its place.
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if grpc.Code(err) != codes.Unknown {
+		return err
+	}
+	switch {
+	case errors.Is(err, errCursorNotFound), errors.Is(err, ErrNotFound):
+		return grpc.Errorf(codes.NotFound, "%s", err.Error())
+	case errors.Is(err, errNoValidators), errors.Is(err, ErrEmptyBlockchain):
+		return grpc.Errorf(codes.FailedPrecondition, "%s", err.Error())
+	case errors.Is(err, ErrInvalidArgument):
+		return grpc.Errorf(codes.InvalidArgument, "%s", err.Error())
+	default:
+		return grpc.Errorf(codes.Internal, "%s", err.Error())
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestToStatusErrorReturnsNilForNilError(t *testing.T) {
+	if err := toStatusError(nil); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+}
+
+func TestToStatusErrorMapsCursorNotFoundToNotFound(t *testing.T) {
+	if err := toStatusError(errCursorNotFound); grpc.Code(err) != codes.NotFound {
+		t.Fatalf("expected codes.NotFound, got %v", err)
+	}
+}
+
+func TestToStatusErrorMapsNoValidatorsToFailedPrecondition(t *testing.T) {
+	if err := toStatusError(errNoValidators); grpc.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected codes.FailedPrecondition, got %v", err)
+	}
+}
+
+func TestToStatusErrorMapsEmptyBlockchainToFailedPrecondition(t *testing.T) {
+	if err := toStatusError(ErrEmptyBlockchain); grpc.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected codes.FailedPrecondition, got %v", err)
+	}
+}
+
+func TestToStatusErrorMapsInvalidArgumentToInvalidArgument(t *testing.T) {
+	wrapped := fmt.Errorf("%w: bad range", ErrInvalidArgument)
+	if err := toStatusError(wrapped); grpc.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected codes.InvalidArgument, got %v", err)
+	}
+}
+
+func TestToStatusErrorMapsUnrecognizedErrorToInternal(t *testing.T) {
+	if err := toStatusError(errors.New("some ledger failure")); grpc.Code(err) != codes.Internal {
+		t.Fatalf("expected codes.Internal, got %v", err)
+	}
+}
+
+func TestToStatusErrorPassesThroughAlreadyCodedError(t *testing.T) {
+	original := grpc.Errorf(codes.Unavailable, "validator unreachable")
+	if err := toStatusError(original); err != original {
+		t.Fatalf("expected the original already-coded error to pass through unchanged, got %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	proto "github.com/golang/protobuf/proto"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// BlockNumber identifies a single block by its position on the chain, for proxied calls that take a
+// block number rather than a hash or range.
+type BlockNumber struct {
+	Number uint64 `protobuf:"varint,1,opt,name=number" json:"number,omitempty"`
+}
+
+func (m *BlockNumber) Reset()         { *m = BlockNumber{} }
+func (m *BlockNumber) String() string { return proto.CompactTextString(m) }
+func (*BlockNumber) ProtoMessage()    {}
+
+// BlockCount carries a count of blocks, returned by proxied calls that report chain height.
+type BlockCount struct {
+	Count uint64 `protobuf:"varint,1,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *BlockCount) Reset()         { *m = BlockCount{} }
+func (m *BlockCount) String() string { return proto.CompactTextString(m) }
+func (*BlockCount) ProtoMessage()    {}
+
+// TransactionQuery identifies a single transaction by id, for proxied calls that look up a
+// transaction.
+type TransactionQuery struct {
+	Txid string `protobuf:"bytes,1,opt,name=txid" json:"txid,omitempty"`
+}
+
+func (m *TransactionQuery) Reset()         { *m = TransactionQuery{} }
+func (m *TransactionQuery) String() string { return proto.CompactTextString(m) }
+func (*TransactionQuery) ProtoMessage()    {}
+
+// BlockRange identifies a contiguous, inclusive span of blocks by number, for batch proxied calls.
+type BlockRange struct {
+	Start uint64 `protobuf:"varint,1,opt,name=start" json:"start,omitempty"`
+	End   uint64 `protobuf:"varint,2,opt,name=end" json:"end,omitempty"`
+}
+
+func (m *BlockRange) Reset()         { *m = BlockRange{} }
+func (m *BlockRange) String() string { return proto.CompactTextString(m) }
+func (*BlockRange) ProtoMessage()    {}
+
+// BlockList carries the blocks returned by a batch proxied call, in ascending block number order.
+type BlockList struct {
+	Blocks []*common.Block `protobuf:"bytes,1,rep,name=blocks" json:"blocks,omitempty"`
+}
+
+func (m *BlockList) Reset()         { *m = BlockList{} }
+func (m *BlockList) String() string { return proto.CompactTextString(m) }
+func (*BlockList) ProtoMessage()    {}
+
+// TransactionList carries the raw transaction envelopes of a single block, in the same order they
+// appear in the block, returned by GetTransactionsByBlockNumber.
+type TransactionList struct {
+	Transactions [][]byte `protobuf:"bytes,1,rep,name=transactions" json:"transactions,omitempty"`
+}
+
+func (m *TransactionList) Reset()         { *m = TransactionList{} }
+func (m *TransactionList) String() string { return proto.CompactTextString(m) }
+func (*TransactionList) ProtoMessage()    {}
+
+// EndorsementStats summarizes the number of endorsements carried by each endorser transaction in a
+// block range, returned by GetAverageEndorsements. TransactionCount counts only the endorser
+// transactions the computation could actually inspect; a transaction whose action payload cannot be
+// decoded is skipped rather than counted as zero endorsements.
+type EndorsementStats struct {
+	TransactionCount uint64  `protobuf:"varint,1,opt,name=transactionCount" json:"transactionCount,omitempty"`
+	Mean             float64 `protobuf:"fixed64,2,opt,name=mean" json:"mean,omitempty"`
+	Min              uint32  `protobuf:"varint,3,opt,name=min" json:"min,omitempty"`
+	Max              uint32  `protobuf:"varint,4,opt,name=max" json:"max,omitempty"`
+}
+
+func (m *EndorsementStats) Reset()         { *m = EndorsementStats{} }
+func (m *EndorsementStats) String() string { return proto.CompactTextString(m) }
+func (*EndorsementStats) ProtoMessage()    {}
+
+// BloomFilter carries a serialized ledgerutil.BloomFilter encoding the transaction IDs of a block
+// range, returned by GetTxIDBloomFilter. K is the number of hash functions the filter was built
+// with, needed alongside Bits to reconstruct it with ledgerutil.NewBloomFilterFromBytes.
+type BloomFilter struct {
+	Bits []byte `protobuf:"bytes,1,opt,name=bits,proto3" json:"bits,omitempty"`
+	K    uint32 `protobuf:"varint,2,opt,name=k" json:"k,omitempty"`
+}
+
+func (m *BloomFilter) Reset()         { *m = BloomFilter{} }
+func (m *BloomFilter) String() string { return proto.CompactTextString(m) }
+func (*BloomFilter) ProtoMessage()    {}
+
+// ServerStatusCode is the health classification reported by Ping.
+type ServerStatusCode int32
+
+const (
+	// ServerStatusOK means the server considers itself fully healthy.
+	ServerStatusOK ServerStatusCode = 0
+	// ServerStatusDegraded means the server can still serve requests but has reduced capacity, for
+	// example every configured validator is in cooldown after a recent transient failure and calls
+	// are forwarded to them regardless.
+	ServerStatusDegraded ServerStatusCode = 1
+	// ServerStatusDown means the server cannot currently serve requests at all.
+	ServerStatusDown ServerStatusCode = 2
+)
+
+// ServerStatus reports the outcome of a Ping health check.
+type ServerStatus struct {
+	Status  ServerStatusCode `protobuf:"varint,1,opt,name=status" json:"status,omitempty"`
+	Message string           `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *ServerStatus) Reset()         { *m = ServerStatus{} }
+func (m *ServerStatus) String() string { return proto.CompactTextString(m) }
+func (*ServerStatus) ProtoMessage()    {}
+
+// FinalityEstimate reports, for EstimateFinality, how long a transaction is expected to take to
+// reach finality (commit into a block). NotPending is true when the transaction is already
+// committed, in which case EstimatedSeconds is meaningless and left at zero.
+type FinalityEstimate struct {
+	NotPending       bool    `protobuf:"varint,1,opt,name=notPending" json:"notPending,omitempty"`
+	EstimatedSeconds float64 `protobuf:"fixed64,2,opt,name=estimatedSeconds" json:"estimatedSeconds,omitempty"`
+}
+
+func (m *FinalityEstimate) Reset()         { *m = FinalityEstimate{} }
+func (m *FinalityEstimate) String() string { return proto.CompactTextString(m) }
+func (*FinalityEstimate) ProtoMessage()    {}
+
+// ChaincodeVersionInfo reports the version currently active for a chaincode, returned by
+// GetChaincodeVersion. Version counts deploy/upgrade transactions the same way lccc does
+// internally: 0 for the initial deploy, incremented once per subsequent upgrade.
+type ChaincodeVersionInfo struct {
+	ChaincodeID string `protobuf:"bytes,1,opt,name=chaincodeID" json:"chaincodeID,omitempty"`
+	Version     int32  `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *ChaincodeVersionInfo) Reset()         { *m = ChaincodeVersionInfo{} }
+func (m *ChaincodeVersionInfo) String() string { return proto.CompactTextString(m) }
+func (*ChaincodeVersionInfo) ProtoMessage()    {}
+
+// FullnessStats reports, for GetBlockFullnessStats, the fraction of blocks in a range at or above
+// the configured "full" transaction-count threshold versus below it.
+type FullnessStats struct {
+	TotalBlockCount uint64  `protobuf:"varint,1,opt,name=totalBlockCount" json:"totalBlockCount,omitempty"`
+	FullBlockCount  uint64  `protobuf:"varint,2,opt,name=fullBlockCount" json:"fullBlockCount,omitempty"`
+	FullFraction    float64 `protobuf:"fixed64,3,opt,name=fullFraction" json:"fullFraction,omitempty"`
+}
+
+func (m *FullnessStats) Reset()         { *m = FullnessStats{} }
+func (m *FullnessStats) String() string { return proto.CompactTextString(m) }
+func (*FullnessStats) ProtoMessage()    {}
+
+// TipLinkage reports the chain tip's block number and hash alongside the hash of the block that
+// precedes it, returned by GetTipLinkage so a client can confirm the tip links to the previous
+// block it already knows about without fetching either block in full.
+type TipLinkage struct {
+	Number       uint64 `protobuf:"varint,1,opt,name=number" json:"number,omitempty"`
+	Hash         []byte `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	PreviousHash []byte `protobuf:"bytes,3,opt,name=previousHash,proto3" json:"previousHash,omitempty"`
+}
+
+func (m *TipLinkage) Reset()         { *m = TipLinkage{} }
+func (m *TipLinkage) String() string { return proto.CompactTextString(m) }
+func (*TipLinkage) ProtoMessage()    {}
+
+// BlockNumberList carries the numbers of blocks matching some predicate, such as the blocks
+// returned by FindLargeBlocks, in ascending order.
+type BlockNumberList struct {
+	Numbers []uint64 `protobuf:"varint,1,rep,name=numbers" json:"numbers,omitempty"`
+}
+
+func (m *BlockNumberList) Reset()         { *m = BlockNumberList{} }
+func (m *BlockNumberList) String() string { return proto.CompactTextString(m) }
+func (*BlockNumberList) ProtoMessage()    {}
+
+// TransactionFormat selects the encoding GetTransactionByIDFormatted returns a transaction in.
+type TransactionFormat int32
+
+const (
+	// TransactionFormatProto returns the transaction as its standard binary protobuf encoding.
+	TransactionFormatProto TransactionFormat = 0
+	// TransactionFormatJSON returns the transaction as compact JSON.
+	TransactionFormatJSON TransactionFormat = 1
+	// TransactionFormatJSONIndented returns the transaction as indented, human-readable JSON.
+	TransactionFormatJSONIndented TransactionFormat = 2
+)
+
+// FormattedTransaction carries a transaction already encoded in the TransactionFormat requested by
+// GetTransactionByIDFormatted, alongside the MIME content type of Payload, so a caller can forward it
+// to a client without needing to know which format was requested.
+type FormattedTransaction struct {
+	Payload     []byte `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	ContentType string `protobuf:"bytes,2,opt,name=contentType" json:"contentType,omitempty"`
+}
+
+func (m *FormattedTransaction) Reset()         { *m = FormattedTransaction{} }
+func (m *FormattedTransaction) String() string { return proto.CompactTextString(m) }
+func (*FormattedTransaction) ProtoMessage()    {}
+
+// TransactionIDList carries the transaction IDs of a single block, in the same order the
+// transactions appear in the block, returned by GetBlockTxIDs.
+type TransactionIDList struct {
+	TxIDs []string `protobuf:"bytes,1,rep,name=txIDs" json:"txIDs,omitempty"`
+}
+
+func (m *TransactionIDList) Reset()         { *m = TransactionIDList{} }
+func (m *TransactionIDList) String() string { return proto.CompactTextString(m) }
+func (*TransactionIDList) ProtoMessage()    {}
+
+// StateQuery identifies a set of chaincode state keys to fetch in a single call.
+type StateQuery struct {
+	ChaincodeID string   `protobuf:"bytes,1,opt,name=chaincodeID" json:"chaincodeID,omitempty"`
+	Keys        []string `protobuf:"bytes,2,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *StateQuery) Reset()         { *m = StateQuery{} }
+func (m *StateQuery) String() string { return proto.CompactTextString(m) }
+func (*StateQuery) ProtoMessage()    {}
+
+// StateValues carries the values fetched by a StateQuery, in the same order as the query's Keys. A
+// nil entry marks a key that does not exist.
+type StateValues struct {
+	Values [][]byte `protobuf:"bytes,1,rep,name=values" json:"values,omitempty"`
+}
+
+func (m *StateValues) Reset()         { *m = StateValues{} }
+func (m *StateValues) String() string { return proto.CompactTextString(m) }
+func (*StateValues) ProtoMessage()    {}
+
+// ValidatorForwardingStats reports one validator's outstanding forwarded-call load, as part of a
+// ForwardingStatsResult.
+type ValidatorForwardingStats struct {
+	Address  string `protobuf:"bytes,1,opt,name=address" json:"address,omitempty"`
+	InFlight uint64 `protobuf:"varint,2,opt,name=inFlight" json:"inFlight,omitempty"`
+	Queued   uint64 `protobuf:"varint,3,opt,name=queued" json:"queued,omitempty"`
+	Errors   uint64 `protobuf:"varint,4,opt,name=errors" json:"errors,omitempty"`
+}
+
+func (m *ValidatorForwardingStats) Reset()         { *m = ValidatorForwardingStats{} }
+func (m *ValidatorForwardingStats) String() string { return proto.CompactTextString(m) }
+func (*ValidatorForwardingStats) ProtoMessage()    {}
+
+// ForwardingStatsResult reports forwarding load for every validator address seen so far, returned
+// by GetForwardingStats. Validators is sorted by Address for a deterministic result.
+type ForwardingStatsResult struct {
+	Validators []*ValidatorForwardingStats `protobuf:"bytes,1,rep,name=validators" json:"validators,omitempty"`
+}
+
+func (m *ForwardingStatsResult) Reset()         { *m = ForwardingStatsResult{} }
+func (m *ForwardingStatsResult) String() string { return proto.CompactTextString(m) }
+func (*ForwardingStatsResult) ProtoMessage()    {}
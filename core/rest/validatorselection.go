@@ -0,0 +1,166 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// defaultValidatorCooldown is used when rest.validatorCooldown is unset in configuration.
+const defaultValidatorCooldown = 30 * time.Second
+
+// validatorCooldown returns the configured duration a validator address is skipped for after a
+// transient failure, reading rest.validatorCooldown and falling back to defaultValidatorCooldown.
+func validatorCooldown() time.Duration {
+	if viper.IsSet("rest.validatorCooldown") {
+		return viper.GetDuration("rest.validatorCooldown")
+	}
+	return defaultValidatorCooldown
+}
+
+// validatorCooldowns tracks, per validator address, the time until which that address should be
+// skipped by selection after a recent transient failure.
+type validatorCooldowns struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+// markFailed puts addr into cooldown for validatorCooldown().
+func (c *validatorCooldowns) markFailed(addr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.until == nil {
+		c.until = map[string]time.Time{}
+	}
+	c.until[addr] = time.Now().Add(validatorCooldown())
+}
+
+// filter returns the subset of addrs not currently in cooldown, preserving order. If every address
+// is in cooldown, filter returns addrs unfiltered rather than leaving the caller with nothing to
+// try, since a validator that is merely slow should not cause a full outage.
+func (c *validatorCooldowns) filter(addrs []string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.until) == 0 {
+		return addrs
+	}
+	now := time.Now()
+	available := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if until, cooling := c.until[addr]; cooling && now.Before(until) {
+			continue
+		}
+		available = append(available, addr)
+	}
+	if len(available) == 0 {
+		return addrs
+	}
+	return available
+}
+
+// allCooling reports whether every address in addrs is currently in cooldown, for callers (like
+// Ping) that need to distinguish "degraded, but filter will still hand out addresses as a last
+// resort" from "healthy", a distinction filter itself intentionally does not expose.
+func (c *validatorCooldowns) allCooling(addrs []string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(addrs) == 0 || len(c.until) == 0 {
+		return false
+	}
+	now := time.Now()
+	for _, addr := range addrs {
+		if until, cooling := c.until[addr]; !cooling || !now.Before(until) {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultCurrentValidatorTTL is used when rest.currentValidatorTTL is unset in configuration.
+const defaultCurrentValidatorTTL = 5 * time.Second
+
+// currentValidatorTTL returns the configured duration a cached validator selection remains eligible
+// for reuse, reading rest.currentValidatorTTL and falling back to defaultCurrentValidatorTTL.
+func currentValidatorTTL() time.Duration {
+	if viper.IsSet("rest.currentValidatorTTL") {
+		return viper.GetDuration("rest.currentValidatorTTL")
+	}
+	return defaultCurrentValidatorTTL
+}
+
+// currentValidatorCache remembers the most recently successful validator address for up to
+// currentValidatorTTL, so forwardToValidator can keep reusing one validator across calls instead of
+// reselecting (and, under "random" selection, reshuffling) on every single call. It does not replace
+// getValidatorAddresses' own selection and cooldown logic: forwardToValidator still computes the full
+// candidate list and simply tries the cached address first when present, falling back to ordinary
+// selection order otherwise.
+type currentValidatorCache struct {
+	mu        sync.Mutex
+	addr      string
+	expiresAt time.Time
+}
+
+// get returns the cached address and true if one is cached and has not yet expired.
+func (c *currentValidatorCache) get() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.addr == "" || time.Now().After(c.expiresAt) {
+		return "", false
+	}
+	return c.addr, true
+}
+
+// set caches addr as the current validator for currentValidatorTTL.
+func (c *currentValidatorCache) set(addr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addr = addr
+	c.expiresAt = time.Now().Add(currentValidatorTTL())
+}
+
+// invalidate clears the cached address if it is still addr, so a failure observed for an address
+// that has already been superseded by a later successful selection does not clobber it.
+func (c *currentValidatorCache) invalidate(addr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.addr == addr {
+		c.addr = ""
+	}
+}
+
+// roundRobinSelector hands out configured validator addresses in rotation, advancing one position
+// per call so consecutive calls spread evenly across the set regardless of request rate.
+type roundRobinSelector struct {
+	next uint64
+}
+
+// order returns addrs rotated to start just after the address returned by the previous call.
+func (r *roundRobinSelector) order(addrs []string) []string {
+	if len(addrs) == 0 {
+		return addrs
+	}
+	start := int(atomic.AddUint64(&r.next, 1)-1) % len(addrs)
+	ordered := make([]string, len(addrs))
+	for i := range addrs {
+		ordered[i] = addrs[(start+i)%len(addrs)]
+	}
+	return ordered
+}
@@ -34,6 +34,10 @@ type Gossip interface {
 	// GetPeers returns a mapping of endpoint --> []discovery.NetworkMember
 	GetPeers() []discovery.NetworkMember
 
+	// GetPeersWithTimeout is like GetPeers, but bounds the time spent waiting on the discovery
+	// layer and returns an error instead of blocking indefinitely if the timeout elapses first
+	GetPeersWithTimeout(timeout time.Duration) ([]discovery.NetworkMember, error)
+
 	// UpdateMetadata updates the self metadata of the discovery layer
 	UpdateMetadata([]byte)
 
@@ -360,6 +360,21 @@ func (g *gossipServiceImpl) GetPeers() []discovery.NetworkMember {
 	return s
 }
 
+// GetPeersWithTimeout is like GetPeers, but bounds the time spent waiting on the discovery
+// layer and returns an error instead of blocking indefinitely if the timeout elapses first
+func (g *gossipServiceImpl) GetPeersWithTimeout(timeout time.Duration) ([]discovery.NetworkMember, error) {
+	resChan := make(chan []discovery.NetworkMember, 1)
+	go func() {
+		resChan <- g.GetPeers()
+	}()
+	select {
+	case members := <-resChan:
+		return members, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("timed out after %s waiting for peer discovery", timeout)
+	}
+}
+
 // Stop stops the gossip component
 func (g *gossipServiceImpl) Stop() {
 	if g.toDie() {
@@ -138,6 +138,31 @@ func newGossipInstanceWithOnlyPull(id int, maxMsgCount int, boot ...int) Gossip
 	return NewGossipServiceWithServer(conf, &naiveCryptoService{}, api.PeerIdentityType(conf.SelfEndpoint))
 }
 
+type slowDiscovery struct {
+	delay time.Duration
+}
+
+func (*slowDiscovery) Self() discovery.NetworkMember    { return discovery.NetworkMember{} }
+func (*slowDiscovery) UpdateMetadata([]byte)             {}
+func (*slowDiscovery) UpdateEndpoint(string)             {}
+func (*slowDiscovery) Stop()                             {}
+func (*slowDiscovery) InitiateSync(peerNum int)          {}
+func (d *slowDiscovery) GetMembership() []discovery.NetworkMember {
+	time.Sleep(d.delay)
+	return []discovery.NetworkMember{{Endpoint: "localhost:1234"}}
+}
+
+func TestGetPeersWithTimeout(t *testing.T) {
+	g := &gossipServiceImpl{disc: &slowDiscovery{delay: time.Second}}
+
+	_, err := g.GetPeersWithTimeout(10 * time.Millisecond)
+	assert.Error(t, err)
+
+	members, err := g.GetPeersWithTimeout(2 * time.Second)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+}
+
 func TestPull(t *testing.T) {
 	t1 := time.Now()
 	// Scenario: Turn off forwarding and use only pull-based gossip.
@@ -18,6 +18,7 @@ package service
 
 import (
 	"sync"
+	"time"
 
 	"github.com/hyperledger/fabric/core/committer"
 	"github.com/hyperledger/fabric/gossip/comm"
@@ -96,6 +97,11 @@ func (g *gossipServiceImpl) GetPeers() []discovery.NetworkMember {
 	return g.gossip.GetPeers()
 }
 
+// GetPeersWithTimeout is like GetPeers, but bounds the time spent waiting on the discovery layer.
+func (g *gossipServiceImpl) GetPeersWithTimeout(timeout time.Duration) ([]discovery.NetworkMember, error) {
+	return g.gossip.GetPeersWithTimeout(timeout)
+}
+
 // UpdateMetadata updates the self metadata of the discovery layer
 func (g *gossipServiceImpl) UpdateMetadata(data []byte) {
 	g.gossip.UpdateMetadata(data)
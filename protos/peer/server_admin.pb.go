@@ -81,10 +81,35 @@ func (m *LogLevelResponse) String() string            { return proto.CompactText
 func (*LogLevelResponse) ProtoMessage()               {}
 func (*LogLevelResponse) Descriptor() ([]byte, []int) { return fileDescriptor11, []int{2} }
 
+type ValidatorStatus struct {
+	IsValidator bool `protobuf:"varint,1,opt,name=isValidator" json:"isValidator,omitempty"`
+}
+
+func (m *ValidatorStatus) Reset()                    { *m = ValidatorStatus{} }
+func (m *ValidatorStatus) String() string            { return proto.CompactTextString(m) }
+func (*ValidatorStatus) ProtoMessage()               {}
+func (*ValidatorStatus) Descriptor() ([]byte, []int) { return fileDescriptor11, []int{3} }
+
+type ServerConfig struct {
+	ValidatorEnabled   bool   `protobuf:"varint,1,opt,name=validatorEnabled" json:"validatorEnabled,omitempty"`
+	TlsEnabled         bool   `protobuf:"varint,2,opt,name=tlsEnabled" json:"tlsEnabled,omitempty"`
+	CouchDBEnabled     bool   `protobuf:"varint,3,opt,name=couchDBEnabled" json:"couchDBEnabled,omitempty"`
+	HistoryDBEnabled   bool   `protobuf:"varint,4,opt,name=historyDBEnabled" json:"historyDBEnabled,omitempty"`
+	ListenAddress      string `protobuf:"bytes,5,opt,name=listenAddress" json:"listenAddress,omitempty"`
+	ChaincodeKeepalive string `protobuf:"bytes,6,opt,name=chaincodeKeepalive" json:"chaincodeKeepalive,omitempty"`
+}
+
+func (m *ServerConfig) Reset()                    { *m = ServerConfig{} }
+func (m *ServerConfig) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig) ProtoMessage()               {}
+func (*ServerConfig) Descriptor() ([]byte, []int) { return fileDescriptor11, []int{4} }
+
 func init() {
 	proto.RegisterType((*ServerStatus)(nil), "protos.ServerStatus")
 	proto.RegisterType((*LogLevelRequest)(nil), "protos.LogLevelRequest")
 	proto.RegisterType((*LogLevelResponse)(nil), "protos.LogLevelResponse")
+	proto.RegisterType((*ValidatorStatus)(nil), "protos.ValidatorStatus")
+	proto.RegisterType((*ServerConfig)(nil), "protos.ServerConfig")
 	proto.RegisterEnum("protos.ServerStatus_StatusCode", ServerStatus_StatusCode_name, ServerStatus_StatusCode_value)
 }
 
@@ -105,6 +130,10 @@ type AdminClient interface {
 	StopServer(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*ServerStatus, error)
 	GetModuleLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*LogLevelResponse, error)
 	SetModuleLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*LogLevelResponse, error)
+	// Return whether this peer is configured as a validator.
+	IsValidator(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*ValidatorStatus, error)
+	// Return the server's effective (non-secret) configuration.
+	GetServerConfig(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*ServerConfig, error)
 }
 
 type adminClient struct {
@@ -160,6 +189,24 @@ func (c *adminClient) SetModuleLogLevel(ctx context.Context, in *LogLevelRequest
 	return out, nil
 }
 
+func (c *adminClient) IsValidator(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*ValidatorStatus, error) {
+	out := new(ValidatorStatus)
+	err := grpc.Invoke(ctx, "/protos.Admin/IsValidator", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetServerConfig(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*ServerConfig, error) {
+	out := new(ServerConfig)
+	err := grpc.Invoke(ctx, "/protos.Admin/GetServerConfig", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Admin service
 
 type AdminServer interface {
@@ -169,6 +216,10 @@ type AdminServer interface {
 	StopServer(context.Context, *google_protobuf1.Empty) (*ServerStatus, error)
 	GetModuleLogLevel(context.Context, *LogLevelRequest) (*LogLevelResponse, error)
 	SetModuleLogLevel(context.Context, *LogLevelRequest) (*LogLevelResponse, error)
+	// Return whether this peer is configured as a validator.
+	IsValidator(context.Context, *google_protobuf1.Empty) (*ValidatorStatus, error)
+	// Return the server's effective (non-secret) configuration.
+	GetServerConfig(context.Context, *google_protobuf1.Empty) (*ServerConfig, error)
 }
 
 func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
@@ -265,6 +316,42 @@ func _Admin_SetModuleLogLevel_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Admin_IsValidator_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf1.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).IsValidator(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/IsValidator",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).IsValidator(ctx, req.(*google_protobuf1.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetServerConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf1.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetServerConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/GetServerConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetServerConfig(ctx, req.(*google_protobuf1.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Admin_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "protos.Admin",
 	HandlerType: (*AdminServer)(nil),
@@ -289,6 +376,14 @@ var _Admin_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SetModuleLogLevel",
 			Handler:    _Admin_SetModuleLogLevel_Handler,
 		},
+		{
+			MethodName: "IsValidator",
+			Handler:    _Admin_IsValidator_Handler,
+		},
+		{
+			MethodName: "GetServerConfig",
+			Handler:    _Admin_GetServerConfig_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: fileDescriptor11,